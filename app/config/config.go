@@ -25,9 +25,54 @@ type AppArgs struct {
 	// Probability - specifies expression to evaluate probability of broken edge
 	Probability string
 
+	// ScenarioFile - if provided, topology events from a file are applied after updates of reliability model
+	ScenarioFile string
+
+	// ReliabilityRoot - station from which dist_from_root variable of probability expression is measured
+	ReliabilityRoot int
+
 	// ProtocolName - specifies protocol
 	ProtocolName string
 
+	// ProtocolParams - specifies protocol parameters (key=value,...)
+	ProtocolParams string
+
+	// ListProtocols - if set, program lists registered protocols and exits
+	ListProtocols bool
+
+	// Termination - specifies global termination mode (protocol|quiescence|stable,$k)
+	Termination string
+
+	// MaxRounds - limit of rounds in engine level termination modes
+	MaxRounds int
+
+	// Engine - specifies simulation engine (goroutines|sequential)
+	Engine string
+
+	// Workers - number of workers used by sequential engine
+	Workers int
+
+	// Seed - seed of random generators (0 - seed based on current time)
+	Seed int64
+
+	// TraceFile - if provided, program records trace of all simulation events to a file
+	TraceFile string
+
+	// TracePayloads - if set, trace contains message payloads instead of their hashes
+	TracePayloads bool
+
+	// Replay - if provided, program replays simulation recorded in given trace file and verifies it
+	Replay string
+
+	// CheckpointFile - if provided, sequential engine saves checkpoints of simulation to a file
+	CheckpointFile string
+
+	// CheckpointEvery - number of rounds between checkpoints
+	CheckpointEvery int
+
+	// Resume - if provided, program resumes simulation from given checkpoint file
+	Resume string
+
 	// Experiment - specifies experiment details
 	Experiment string
 }
@@ -40,11 +85,33 @@ func (args *AppArgs) parseArgs() {
 	flag.StringVar(&args.GraphType, "graph-type", "", "provide graph-type "+
 		"(path,$number_of_vertices|clique,$number_of_vertices|regular,$number_of_vertices,$degree|grid,$height,$width|hypercube,$dimension"+
 		"|tree,$number_of_vertices,$degree|gridOfCliques,$height,$width,$number_of_vertices_in_clique)")
-	flag.StringVar(&args.ReliabilityModel, "reliability-model", "", "specifies reliability model")
-	flag.StringVar(&args.Probability, "p", "0.0", "specifies probability expression for reliability model")
-	flag.StringVar(&args.ProtocolName, "protocol", "", "specifies protocol ('hll'|'minPropagation')")
+	flag.StringVar(&args.ReliabilityModel, "reliability-model", "", "specifies reliability model "+
+		"(edge-remover|edge-remover-adder|gilbert-elliott - per edge p_gb and p_bg from graph file, p_gb=p and p_bg=1-p by default)")
+	flag.StringVar(&args.Probability, "p", "0.0", "specifies probability expression for reliability model "+
+		"(using only n - evaluated once, using r, deg_u, deg_v, dist_from_root, reliability or edge attributes "+
+		"from graph file - evaluated for every edge in every round)")
+	flag.StringVar(&args.ScenarioFile, "scenario", "", "apply timed topology events from JSON file "+
+		"(remove|add edges, isolate station, partition into groups, restore original topology)")
+	flag.IntVar(&args.ReliabilityRoot, "reliability-root", 0, "station from which dist_from_root of probability expression is measured")
+	flag.StringVar(&args.ProtocolName, "protocol", "", "specifies protocol (see -list-protocols)")
+	flag.StringVar(&args.ProtocolParams, "protocol-params", "", "specifies protocol parameters (key=value,...)")
+	flag.BoolVar(&args.ListProtocols, "list-protocols", false, "list available protocols with their parameters")
+	flag.StringVar(&args.Termination, "termination", "protocol", "specifies termination mode "+
+		"(protocol - stop condition of protocol|quiescence - no message sent in round|stable,$k - no state change for k rounds)")
+	flag.IntVar(&args.MaxRounds, "max-rounds", 1000, "limit of rounds in quiescence and stable termination modes")
+	flag.StringVar(&args.Engine, "engine", "goroutines", "specifies simulation engine "+
+		"(goroutines - goroutine per station|sequential - stations processed in a loop by fixed number of workers)")
+	flag.IntVar(&args.Workers, "workers", 1, "number of workers used by sequential engine")
+	flag.Int64Var(&args.Seed, "seed", 0, "seed of random generators (0 - seed based on current time)")
+	flag.StringVar(&args.TraceFile, "trace-file", "", "record trace of simulation events to file (JSON lines)")
+	flag.BoolVar(&args.TracePayloads, "trace-payloads", false, "save message payloads in trace instead of their hashes")
+	flag.StringVar(&args.Replay, "replay", "", "replay simulation recorded in given trace file and verify its events")
+	flag.StringVar(&args.CheckpointFile, "checkpoint-file", "", "save checkpoints of simulation to file (switches to sequential engine)")
+	flag.IntVar(&args.CheckpointEvery, "checkpoint-every", 100, "number of rounds between checkpoints")
+	flag.StringVar(&args.Resume, "resume", "", "resume simulation from given checkpoint file")
 	flag.StringVar(&args.Experiment, "experiment", "", "specifies experiment details "+
-		"('extremaPropagation,$min,$max,$step,$repetitions'|countDistinct,$min,$max,$step,$repetitions')")
+		"('extremaPropagation,$min,$max,$step,$repetitions'|countDistinct,$min,$max,$step,$repetitions'"+
+		"|'engineBenchmark,$min,$max,$step,$repetitions')")
 }
 
 // InitializeAppArgs - initializes and validates arguments
@@ -53,6 +120,10 @@ func InitializeAppArgs() AppArgs {
 	args.parseArgs()
 	flag.Parse()
 
+	if args.ListProtocols || args.Replay != "" || args.Resume != "" {
+		return args
+	}
+
 	if args.GraphFile != "" && args.GraphType != "" {
 		log.Fatal("You cannot use graph file while trying to build predefined graph")
 	} else if args.GraphFile == "" && args.GraphType == "" && args.Experiment == "" {
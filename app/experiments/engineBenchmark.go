@@ -0,0 +1,117 @@
+package experiments
+
+import (
+	"app/simulation"
+	"app/simulationGraph"
+	"app/utils"
+	"fmt"
+	"log"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// engineConfiguration - simulation engine compared in benchmark
+type engineConfiguration struct {
+	name       string
+	engine     string
+	nofWorkers int
+}
+
+// EngineBenchmarkExperiment - compares running time of goroutine and sequential engines
+// running min propagation on square grids, prints mean time of repetitions and speedup.
+// Benchmark fails unless all engines give equal statistics for every registered protocol
+func EngineBenchmarkExperiment(experimentDetails string) {
+	params := strings.Split(experimentDetails, ",")
+	min := utils.ParseStrToPositiveInt(params[1])
+	max := utils.ParseStrToPositiveInt(params[2])
+	step := utils.ParseStrToPositiveInt(params[3])
+	repetitions := utils.ParseStrToPositiveInt(params[4])
+	p, err := simulation.NewProtocol("minPropagation", nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	nofWorkers := runtime.NumCPU()
+	if nofWorkers < 2 {
+		nofWorkers = 2
+	}
+	configurations := []engineConfiguration{
+		{"goroutines", simulation.GoroutineEngine, 1},
+		{"sequential", simulation.SequentialEngine, 1},
+		{fmt.Sprintf("sequential-%d", nofWorkers), simulation.SequentialEngine, nofWorkers},
+	}
+
+	verifyEngines(configurations)
+
+	fmt.Printf("%10s", "stations")
+	for _, c := range configurations {
+		fmt.Printf(" %16s", c.name)
+	}
+	fmt.Printf(" %10s %10s\n", "speedup", "speedup-w")
+
+	for i := min; i <= max; i += step {
+		g := simulationGraph.BuildGrid(i, i, "", "")
+		g.SetDiameter(2 * (i - 1))
+
+		times := make([]time.Duration, len(configurations))
+		for c, configuration := range configurations {
+			for j := 0; j < repetitions; j++ {
+				manager := simulation.NewManager("", g)
+				if err := manager.SetEngine(configuration.engine, configuration.nofWorkers); err != nil {
+					log.Fatal(err)
+				}
+
+				start := time.Now()
+				result := manager.RunSimulation(p)
+				times[c] += time.Since(start)
+				checkMinPropagationResult(result, configuration.name)
+			}
+			times[c] /= time.Duration(repetitions)
+		}
+
+		fmt.Printf("%10d", i*i)
+		for _, t := range times {
+			fmt.Printf(" %16s", t.Round(time.Microsecond))
+		}
+		fmt.Printf(" %10.2f %10.2f\n", times[0].Seconds()/times[1].Seconds(), times[0].Seconds()/times[2].Seconds())
+	}
+}
+
+// verifyEngines - runs every registered protocol with default parameters and the same seed in all
+// configurations, fails if statistics differ from the first configuration
+func verifyEngines(configurations []engineConfiguration) {
+	g := simulationGraph.BuildGrid(6, 6, "", "")
+	g.SetDiameter(10)
+
+	for _, info := range simulation.GetRegisteredProtocols() {
+		var expected simulation.JsonStatsStructure
+		for c, configuration := range configurations {
+			p, err := simulation.NewProtocol(info.Name, nil)
+			if err != nil {
+				log.Fatal(err)
+			}
+			manager := simulation.NewManager("", g)
+			manager.SetSeed(1)
+			if err := manager.SetEngine(configuration.engine, configuration.nofWorkers); err != nil {
+				log.Fatal(err)
+			}
+
+			result := manager.RunSimulation(p)
+			if c == 0 {
+				expected = result
+			} else if diff := simulation.DiffStats(expected, result); diff != "" {
+				log.Fatalf("%s: %s engine differs from %s engine: %s", info.Name, configuration.name, configurations[0].name, diff)
+			}
+		}
+	}
+}
+
+// checkMinPropagationResult - verifies that every station found the global minimum
+func checkMinPropagationResult(result simulation.JsonStatsStructure, engine string) {
+	for _, station := range result.Stations {
+		if station.Result != station.ExactResult {
+			log.Fatalf("%s engine: station result %v differs from exact result %v", engine, station.Result, station.ExactResult)
+		}
+	}
+}
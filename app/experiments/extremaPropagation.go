@@ -6,6 +6,7 @@ import (
 	"app/simulationGraph"
 	"app/utils"
 	"fmt"
+	"log"
 	"strings"
 )
 
@@ -15,6 +16,10 @@ func ExtremaPropagationExperiment(experimentDetails string) {
 	max := utils.ParseStrToPositiveInt(params[2])
 	step := utils.ParseStrToPositiveInt(params[3])
 	repetitions := utils.ParseStrToPositiveInt(params[4])
+	p, err := simulation.NewProtocol("minPropagation", nil)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	for i := min; i <= max; i += step {
 		g := simulationGraph.BuildPath(i, "", "")
@@ -22,7 +27,7 @@ func ExtremaPropagationExperiment(experimentDetails string) {
 
 		for j := 0; j < repetitions; j++ {
 			manager := simulation.NewManager("", g)
-			result := manager.RunSimulation("minPropagation")
+			result := manager.RunSimulation(p)
 			filepath := fmt.Sprintf("%s_%d_%d.json", "results/extremaPropagation/min_propagation", i, j)
 			io.SaveStatistics(filepath, result)
 		}
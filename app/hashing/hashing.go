@@ -0,0 +1,72 @@
+package hashing
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+)
+
+// HashFunction - hash function used by probabilistic data structures
+type HashFunction struct {
+	// Name - name used to select hash function
+	Name string
+	// Bits - number of meaningful bits of produced hash (32 or 64)
+	Bits uint
+	// Sum - returns hash of given data (only lowest Bits bits are used)
+	Sum func(data []byte) uint64
+}
+
+var hashFunctions = map[string]HashFunction{
+	"fnv32":   {Name: "fnv32", Bits: 32, Sum: Fnv32},
+	"fnv64":   {Name: "fnv64", Bits: 64, Sum: Fnv64},
+	"xxhash":  {Name: "xxhash", Bits: 64, Sum: XXHash64},
+	"murmur3": {Name: "murmur3", Bits: 64, Sum: Murmur3},
+}
+
+// Get - returns hash function with given name
+func Get(name string) (HashFunction, error) {
+	h, ok := hashFunctions[name]
+	if !ok {
+		return HashFunction{}, fmt.Errorf("unknown hash function %q (available: %s)", name, strings.Join(Names(), ", "))
+	}
+
+	return h, nil
+}
+
+// Names - returns names of available hash functions
+func Names() []string {
+	names := make([]string, 0, len(hashFunctions))
+	for name := range hashFunctions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Fnv32 - 32-bit FNV-1 hash
+func Fnv32(data []byte) uint64 {
+	h := fnv.New32()
+	h.Write(data)
+	return uint64(h.Sum32())
+}
+
+// Fnv64 - 64-bit FNV-1a hash
+func Fnv64(data []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(data)
+	return h.Sum64()
+}
+
+// Positions - returns k positions in [0, m) for given data using double hashing
+// (Kirsch, Mitzenmacher) with xxHash and MurmurHash3 as base functions
+func Positions(data []byte, k int, m uint64) []uint64 {
+	h1, h2 := XXHash64(data), Murmur3(data)
+	positions := make([]uint64, k)
+	for i := 0; i < k; i++ {
+		positions[i] = (h1 + uint64(i)*h2) % m
+	}
+
+	return positions
+}
@@ -0,0 +1,42 @@
+package hashing
+
+import "testing"
+
+func TestXXHash64Vectors(t *testing.T) {
+	// reference values of XXH64 with seed 0
+	tests := []struct {
+		data string
+		sum  uint64
+	}{
+		{"", 0xef46db3751d8e999},
+		{"a", 0xd24ec4f1a98c6e5b},
+		{"abc", 0x44bc2cf5ad770999},
+		{"Nobody inspects the spammish repetition", 0xfbcea83c8a378bf1},
+	}
+
+	for _, test := range tests {
+		if sum := XXHash64([]byte(test.data)); sum != test.sum {
+			t.Errorf("XXHash64(%q) = %016x, expected %016x", test.data, sum, test.sum)
+		}
+	}
+}
+
+func TestMurmur3Vectors(t *testing.T) {
+	// reference values of first 64 bits of MurmurHash3 x64_128 with seed 0
+	tests := []struct {
+		data string
+		sum  uint64
+	}{
+		{"", 0x0000000000000000},
+		{"hello", 0xcbd8a7b341bd9b02},
+		{"hello, world", 0x342fac623a5ebc8e},
+		{"19 Jan 2038 at 3:14:07 AM", 0xb89e5988b737affc},
+		{"The quick brown fox jumps over the lazy dog.", 0xcd99481f9ee902c9},
+	}
+
+	for _, test := range tests {
+		if sum := Murmur3([]byte(test.data)); sum != test.sum {
+			t.Errorf("Murmur3(%q) = %016x, expected %016x", test.data, sum, test.sum)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package hashing
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+const (
+	murmurC1 uint64 = 0x87c37b91114253d5
+	murmurC2 uint64 = 0x4cf5ad432745937f
+)
+
+// Murmur3 - first 64 bits of MurmurHash3 x64_128 with seed 0
+func Murmur3(data []byte) uint64 {
+	n := len(data)
+	var h1, h2 uint64
+
+	for len(data) >= 16 {
+		k1 := binary.LittleEndian.Uint64(data[0:8])
+		k2 := binary.LittleEndian.Uint64(data[8:16])
+
+		h1 ^= murmurMixK1(k1)
+		h1 = bits.RotateLeft64(h1, 27)
+		h1 += h2
+		h1 = h1*5 + 0x52dce729
+
+		h2 ^= murmurMixK2(k2)
+		h2 = bits.RotateLeft64(h2, 31)
+		h2 += h1
+		h2 = h2*5 + 0x38495ab5
+
+		data = data[16:]
+	}
+
+	// tail - remaining 0..15 bytes
+	var k1, k2 uint64
+	for i := len(data) - 1; i >= 8; i-- {
+		k2 ^= uint64(data[i]) << (8 * uint(i-8))
+	}
+	if len(data) > 8 {
+		h2 ^= murmurMixK2(k2)
+	}
+	for i := min(len(data), 8) - 1; i >= 0; i-- {
+		k1 ^= uint64(data[i]) << (8 * uint(i))
+	}
+	if len(data) > 0 {
+		h1 ^= murmurMixK1(k1)
+	}
+
+	h1 ^= uint64(n)
+	h2 ^= uint64(n)
+	h1 += h2
+	h2 += h1
+	h1 = murmurFmix64(h1)
+	h2 = murmurFmix64(h2)
+	h1 += h2
+
+	return h1
+}
+
+func murmurMixK1(k uint64) uint64 {
+	k *= murmurC1
+	k = bits.RotateLeft64(k, 31)
+	return k * murmurC2
+}
+
+func murmurMixK2(k uint64) uint64 {
+	k *= murmurC2
+	k = bits.RotateLeft64(k, 33)
+	return k * murmurC1
+}
+
+func murmurFmix64(k uint64) uint64 {
+	k ^= k >> 33
+	k *= 0xff51afd7ed558ccd
+	k ^= k >> 33
+	k *= 0xc4ceb9fe1a85ec53
+	k ^= k >> 33
+	return k
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,17 @@
+package hashing
+
+import "testing"
+
+func TestPositionsInRange(t *testing.T) {
+	for _, m := range []uint64{1, 7, 1024} {
+		positions := Positions([]byte("station"), 5, m)
+		if len(positions) != 5 {
+			t.Fatalf("expected 5 positions, got %d", len(positions))
+		}
+		for _, p := range positions {
+			if p >= m {
+				t.Errorf("position %d out of range [0, %d)", p, m)
+			}
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package simulation
+
+import (
+	"app/hashing"
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// bloomBitsPerWord - number of filter bits packed into single float64 of message data
+const bloomBitsPerWord = 32
+
+// BloomFilterProtocol - set union protocol, stations flood Bloom filters of observed values merged by bitwise OR
+type BloomFilterProtocol struct {
+	valueObserver
+	size       int // number of bits
+	nofHashes  int
+	nofFpTests int
+}
+
+// bloomFilterState - state of station in Bloom filter protocol
+type bloomFilterState struct {
+	filterChanged bool
+}
+
+func init() {
+	RegisterProtocol(ProtocolInfo{
+		Name:        "bloomFilter",
+		Description: "compute union of observed values using Bloom filters propagated by flooding",
+		Parameters: append([]ProtocolParameter{
+			{Name: "size", Type: IntParameter, Default: "1024", Description: "number of bits in filter"},
+			{Name: "hashes", Type: IntParameter, Default: "4", Description: "number of hash functions"},
+			{Name: "fp-tests", Type: IntParameter, Default: "10000", Description: "number of non-member queries used to measure false positive rate"},
+		}, observationParameters()...),
+		Factory: func(params ProtocolParams) (Protocol, error) {
+			observer, err := newValueObserver(params)
+			if err != nil {
+				return nil, err
+			}
+
+			p := BloomFilterProtocol{
+				valueObserver: observer,
+				size:          params.Int("size"),
+				nofHashes:     params.Int("hashes"),
+				nofFpTests:    params.Int("fp-tests"),
+			}
+			if p.size <= 0 || p.nofHashes <= 0 || p.nofFpTests <= 0 {
+				return nil, fmt.Errorf("size, hashes and fp-tests should be positive")
+			}
+
+			return WithState[bloomFilterState](p), nil
+		},
+	})
+}
+
+func (p BloomFilterProtocol) GetInitialData(station IStation, state *bloomFilterState) {
+	filter := make([]float64, (p.size+bloomBitsPerWord-1)/bloomBitsPerWord)
+	for _, b := range p.observeValues(station) {
+		for _, position := range hashing.Positions(b, p.nofHashes, uint64(p.size)) {
+			word := position / bloomBitsPerWord
+			filter[word] = float64(uint32(filter[word]) | 1<<(position%bloomBitsPerWord))
+		}
+	}
+
+	station.SetCurrentData(filter)
+}
+
+func (BloomFilterProtocol) OnInitialize(station IStation, state *bloomFilterState) {
+	station.Broadcast()
+}
+
+func (BloomFilterProtocol) OnDataReceive(station IStation, state *bloomFilterState) {
+	state.filterChanged = false
+	mq := station.GetMsgQueue()
+	// copy, current data may still be read by neighbours
+	filter := append([]float64{}, station.GetCurrentData()...)
+	for mq.Len() > 0 {
+		msg := mq.Dequeue()
+		for i, word := range msg.Data {
+			merged := float64(uint32(filter[i]) | uint32(word))
+			if merged != filter[i] {
+				state.filterChanged = true
+				filter[i] = merged
+			}
+		}
+	}
+
+	if state.filterChanged {
+		station.SetCurrentData(filter)
+	}
+}
+
+func (BloomFilterProtocol) OnDataPropagate(station IStation, state *bloomFilterState) {
+	if state.filterChanged {
+		station.SynchronizedBroadcast()
+	}
+}
+
+func (BloomFilterProtocol) StateChanged(station IStation, state *bloomFilterState) bool {
+	return state.filterChanged
+}
+
+func (BloomFilterProtocol) StopCondition(station IStation, state *bloomFilterState) bool {
+	return station.GetRoundCounter() < station.GetGraph().GetDiameter()
+}
+
+// OnFinalize - station result is cardinality estimated from number of set bits
+func (p BloomFilterProtocol) OnFinalize(station IStation, state *bloomFilterState) {
+	setBits := 0
+	for _, word := range station.GetCurrentData() {
+		setBits += bits.OnesCount32(uint32(word))
+	}
+
+	m, k := float64(p.size), float64(p.nofHashes)
+	if setBits == p.size {
+		// saturated filter, estimate is capped
+		setBits--
+	}
+	station.SetResult(math.Round(-m / k * math.Log(1-float64(setBits)/m)))
+}
+
+func (BloomFilterProtocol) CalculateStationExactResult(station IStation, state *bloomFilterState) float64 {
+	return -1
+}
+
+func (BloomFilterProtocol) CalculateGlobalExactResult(stations *[]IStation) float64 {
+	return float64(len(exactUnion(stations)))
+}
+
+func (p BloomFilterProtocol) contains(filter []float64, value float64) bool {
+	for _, position := range hashing.Positions(valueToBytes(value), p.nofHashes, uint64(p.size)) {
+		if uint32(filter[position/bloomBitsPerWord])&(1<<(position%bloomBitsPerWord)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (p BloomFilterProtocol) CalculateProtocolStats(stations *[]IStation) map[string]interface{} {
+	union := exactUnion(stations)
+	sumFpr, maxFpr, sumFnr := 0., 0., 0.
+	for _, station := range *stations {
+		filter := station.GetCurrentData()
+
+		// values above maxValue are never observed
+		falsePositives := 0
+		for i := 1; i <= p.nofFpTests; i++ {
+			if p.contains(filter, float64(p.maxValue+i)) {
+				falsePositives++
+			}
+		}
+		fpr := float64(falsePositives) / float64(p.nofFpTests)
+		sumFpr += fpr
+		maxFpr = math.Max(maxFpr, fpr)
+
+		// members of union which did not reach the station
+		falseNegatives := 0
+		for value := range union {
+			if !p.contains(filter, value) {
+				falseNegatives++
+			}
+		}
+		sumFnr += float64(falseNegatives) / float64(len(union))
+	}
+
+	n := float64(len(*stations))
+	m, k := float64(p.size), float64(p.nofHashes)
+	return map[string]interface{}{
+		"avg_false_positive_rate":      sumFpr / n,
+		"max_false_positive_rate":      maxFpr,
+		"expected_false_positive_rate": math.Pow(1-math.Exp(-k*float64(len(union))/m), k),
+		"avg_false_negative_rate":      sumFnr / n,
+	}
+}
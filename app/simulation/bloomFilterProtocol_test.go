@@ -0,0 +1,41 @@
+package simulation
+
+import (
+	"app/simulationGraph"
+	"math"
+	"testing"
+)
+
+func TestBloomFilterEstimatesUnion(t *testing.T) {
+	tests := []struct {
+		name     string
+		g        *simulationGraph.GraphWrapper
+		diameter int
+		params   string
+	}{
+		{"path", simulationGraph.BuildPath(6, "", "0"), 5, "size=8192"},
+		{"grid", simulationGraph.BuildGrid(4, 4, "", "0"), 6, "size=8192,hashes=3"},
+		// small value range - stations observe common values
+		{"clique", simulationGraph.BuildClique(8, "", "0"), 1, "size=4096,max=100"},
+	}
+
+	for _, test := range tests {
+		result := runOnGraph(t, "bloomFilter", test.params, test.g, test.diameter)
+		union := result.Result
+		for _, station := range result.Stations {
+			// all filters are the same after flooding, estimate error is a few percent for these loads
+			if math.Abs(station.Result-union) > 0.05*union {
+				t.Errorf("%s: station %d estimated union of %v values, exact %v", test.name, station.GetId(), station.Result, union)
+			}
+		}
+
+		stats := result.ProtocolStats
+		if fnr := stats["avg_false_negative_rate"].(float64); fnr != 0 {
+			t.Errorf("%s: false negative rate %v after flooding on reliable graph", test.name, fnr)
+		}
+		expected := stats["expected_false_positive_rate"].(float64)
+		if fpr := stats["max_false_positive_rate"].(float64); fpr > 2*expected+0.005 {
+			t.Errorf("%s: false positive rate %v, expected about %v", test.name, fpr, expected)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package simulation
+
+import (
+	"app/simulationGraph"
+	"fmt"
+	"log"
+	"os"
+)
+
+// checkpointFormat - identifies checkpoint files and version of their format
+const checkpointFormat = "simulation-checkpoint-3"
+
+// checkpoint - state of simulation saved by sequential engine at round boundary, after all stations
+// propagated data in Round and before edges are updated for the next round, in checkpoint file it is
+// preceded by configuration, so protocol registers types of its state before state is read
+type checkpoint struct {
+	Round    int
+	Edges    [][2]int // edges present in graph, original edges missing here were removed by reliability model
+	Stations []stationSnapshot
+	// Fingerprints - fingerprints of station states used to detect state changes
+	Fingerprints  []uint64
+	UpdaterRandom randomState
+	// UpdaterState - state of edge updater, nil if updater does not keep state
+	UpdaterState interface{}
+	// Reliability - state of time-varying reliability expression
+	Reliability   expressionState
+	RoundSentMsgs int64
+	StateChanged  int32
+	StableRounds  int
+}
+
+// stationSnapshot - state of station saved in checkpoint
+type stationSnapshot struct {
+	CurrentData           []float64
+	HistoricalData        [][]float64
+	ObservedValues        [][]float64
+	UserDefinedVariables  map[string]interface{}
+	State                 interface{}
+	Queue                 []*Pack
+	NextInbox             []*Pack // messages sent in Round, received in the next round
+	SentMsgCounter        int
+	ReceivedMsgCounter    int
+	UndeliveredMsgCounter int
+	RoundCounter          int
+	Result                float64
+	MaxQueueSize          int
+	Stopped               bool
+	Random                randomState
+}
+
+// SetCheckpointing - makes sequential engine save checkpoint to given file every given number of rounds,
+// configuration is saved in checkpoint so that simulation can be resumed by ResumeFromCheckpoint.
+// Simulation set up for goroutine engine is switched to sequential engine, engines give equal results
+func (m *Manager) SetCheckpointing(path string, every int, conf RunConfig) error {
+	if every <= 0 {
+		return fmt.Errorf("checkpoint interval should be positive, got %d", every)
+	}
+	if m.engine != SequentialEngine {
+		log.Printf("checkpoints are saved only by %s engine, switching from %s engine", SequentialEngine, m.engine)
+		if err := m.SetEngine(SequentialEngine, m.nofWorkers); err != nil {
+			return err
+		}
+		conf.Engine = SequentialEngine
+	}
+
+	m.checkpointPath = path
+	m.checkpointEvery = every
+	m.runConfig = conf
+	return nil
+}
+
+// CheckpointError - returns error which occurred while saving checkpoint, simulation continues
+// without further checkpoints after the first error
+func (m *Manager) CheckpointError() error {
+	return m.checkpointErr
+}
+
+// ResumeFromCheckpoint - recreates simulation saved in checkpoint file, RunSimulation of returned manager
+// called with returned protocol continues simulation from the round in which checkpoint was saved
+func ResumeFromCheckpoint(path string) (Protocol, *Manager, RunConfig, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, RunConfig{}, err
+	}
+	defer file.Close()
+
+	decoder := newSnapshotDecoder(file)
+	var format string
+	if err := decoder.decode(&format); err != nil || format != checkpointFormat {
+		return nil, nil, RunConfig{}, fmt.Errorf("%s is not a checkpoint file", path)
+	}
+	var conf RunConfig
+	if err := decoder.decode(&conf); err != nil {
+		return nil, nil, RunConfig{}, fmt.Errorf("invalid checkpoint %s: %v", path, err)
+	}
+
+	p, manager, err := conf.build()
+	if err != nil {
+		return nil, nil, RunConfig{}, err
+	}
+	cp := &checkpoint{}
+	if err := decoder.decode(cp); err != nil {
+		return nil, nil, RunConfig{}, fmt.Errorf("invalid checkpoint %s: %v", path, err)
+	}
+	if manager.engine != SequentialEngine || len(cp.Stations) != manager.nofStations {
+		return nil, nil, RunConfig{}, fmt.Errorf("checkpoint %s does not match its configuration", path)
+	}
+	manager.resumeFrom = cp
+
+	return p, manager, conf, nil
+}
+
+// checkpointDue - returns true if checkpoint should be saved after current round
+func (m *Manager) checkpointDue() bool {
+	return m.checkpointEvery > 0 && m.checkpointErr == nil && m.round > 0 &&
+		m.round%m.checkpointEvery == 0 && (m.resumeFrom == nil || m.round != m.resumeFrom.Round)
+}
+
+// saveCheckpoint - writes state of simulation to temporary file and renames it,
+// so previous checkpoint stays valid if process dies while saving
+func (m *Manager) saveCheckpoint(fingerprints []uint64) error {
+	cp := &checkpoint{
+		Round:         m.round,
+		Edges:         currentEdges(m.graph),
+		Stations:      make([]stationSnapshot, 0, m.nofStations),
+		Fingerprints:  fingerprints,
+		RoundSentMsgs: m.roundSentMsgs,
+		StateChanged:  m.stateChanged,
+		StableRounds:  m.stableRounds,
+	}
+	if m.updaterSource != nil {
+		cp.UpdaterRandom = m.updaterSource.state()
+	}
+	if updater, ok := m.updater.(statefulUpdater); ok {
+		cp.UpdaterState = updater.saveState()
+	}
+	if m.reliability != nil {
+		cp.Reliability = m.reliability.state
+	}
+	for _, s := range m.sequentialStations() {
+		cp.Stations = append(cp.Stations, s.snapshot())
+	}
+
+	tmpPath := m.checkpointPath + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	encoder := newSnapshotEncoder(file)
+	format := checkpointFormat
+	encoder.encode(&format)
+	encoder.encode(&m.runConfig)
+	encoder.encode(cp)
+	if err := encoder.flush(); err != nil {
+		file.Close()
+		return fmt.Errorf("cannot save checkpoint: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, m.checkpointPath)
+}
+
+// restoreCheckpoint - brings graph, stations, random generators and termination state
+// to the state saved in checkpoint, called after edge updater was created
+func (m *Manager) restoreCheckpoint(cp *checkpoint, fingerprints []uint64) {
+	present := map[[2]int]bool{}
+	for _, e := range cp.Edges {
+		present[e] = true
+	}
+	for _, e := range currentEdges(m.graph) {
+		if !present[e] {
+			m.graph.GraphStructure.DeleteBoth(e[0], e[1])
+		}
+	}
+	for _, e := range cp.Edges {
+		m.graph.GraphStructure.AddBoth(e[0], e[1])
+	}
+
+	for i, s := range m.sequentialStations() {
+		s.restore(cp.Stations[i])
+	}
+	copy(fingerprints, cp.Fingerprints)
+	if m.updaterSource != nil {
+		m.updaterSource.restore(cp.UpdaterRandom)
+	}
+	if m.reliability != nil {
+		m.reliability.state = cp.Reliability
+	}
+	if updater, ok := m.updater.(statefulUpdater); ok && cp.UpdaterState != nil {
+		updater.restoreState(cp.UpdaterState)
+	}
+	m.roundSentMsgs = cp.RoundSentMsgs
+	m.stateChanged = cp.StateChanged
+	m.stableRounds = cp.StableRounds
+	m.round = cp.Round
+	m.roundOpen = true
+}
+
+// currentEdges - returns edges (v < w) present in graph
+func currentEdges(g *simulationGraph.GraphWrapper) [][2]int {
+	edges := make([][2]int, 0)
+	for v := 0; v < g.GraphStructure.Order(); v++ {
+		g.GraphStructure.Visit(v, func(w int, c int64) (skip bool) {
+			if v < w {
+				edges = append(edges, [2]int{v, w})
+			}
+			return
+		})
+	}
+
+	return edges
+}
@@ -0,0 +1,51 @@
+package simulation
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestResumedRunEqualsUninterruptedRun(t *testing.T) {
+	tests := []struct {
+		protocol         string
+		params           string
+		reliabilityModel string
+		engine           string
+		nofWorkers       int
+		every            int
+	}{
+		{"rumor", "model=sir", "edge-remover", SequentialEngine, 4, 20},
+		{"kMeans", "iterations=3", "gilbert-elliott", SequentialEngine, 1, 25},
+		{"lubyMis", "", "edge-remover-adder", SequentialEngine, 4, 10},
+		{"quantile", "", "", GoroutineEngine, 1, 5},
+		{"consensus", "variant=wmsr,byzantine=3;17,rounds=30", "", GoroutineEngine, 1, 7},
+	}
+
+	for _, test := range tests {
+		conf := testRunConfig(test.protocol, test.params, test.reliabilityModel, test.engine, test.nofWorkers)
+		p, manager, err := conf.build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(t.TempDir(), "checkpoint")
+		if err := manager.SetCheckpointing(path, test.every, conf); err != nil {
+			t.Fatal(err)
+		}
+		uninterrupted := manager.RunSimulation(p)
+		if err := manager.CheckpointError(); err != nil {
+			t.Fatal(err)
+		}
+
+		// checkpoint file keeps the last checkpoint saved before the end of simulation
+		p, resumed, _, err := ResumeFromCheckpoint(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resumed.resumeFrom.Round == 0 || resumed.resumeFrom.Round >= uninterrupted.NofRounds {
+			t.Fatalf("%s: checkpoint of round %d is not inside run of %d rounds", test.protocol, resumed.resumeFrom.Round, uninterrupted.NofRounds)
+		}
+		if diff := DiffStats(uninterrupted, resumed.RunSimulation(p)); diff != "" {
+			t.Errorf("%s resumed from round %d: %s", test.protocol, resumed.resumeFrom.Round, diff)
+		}
+	}
+}
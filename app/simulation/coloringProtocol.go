@@ -0,0 +1,166 @@
+package simulation
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// ColoringProtocol - randomized (deg+1)-coloring, each phase takes two rounds: uncolored stations
+// send tentative colors from their palettes and keep them if no neighbour chose the same one,
+// then final colors are announced and removed from neighbours' palettes
+type ColoringProtocol struct {
+	nofPhases int // 0 - chosen automatically based on number of stations
+}
+
+// coloringState - state of station in randomized coloring
+type coloringState struct {
+	palette     map[int]struct{} // colors not taken by neighbours
+	colored     bool
+	justColored bool // station kept its tentative color in current phase and announces it
+}
+
+func init() {
+	RegisterProtocol(ProtocolInfo{
+		Name:        "coloring",
+		Description: "compute proper (deg+1)-coloring using randomized trials",
+		Parameters: []ProtocolParameter{
+			{Name: "phases", Type: IntParameter, Default: "0", Description: "number of phases (0 - 4*log2(n)+1)"},
+		},
+		Factory: func(params ProtocolParams) (Protocol, error) {
+			return WithState[coloringState](ColoringProtocol{nofPhases: params.Int("phases")}), nil
+		},
+	})
+}
+
+func (ColoringProtocol) GetInitialData(station IStation, state *coloringState) {
+	// palette {0, ..., deg}
+	state.palette = map[int]struct{}{}
+	for c := 0; c <= len(station.GetNeighbours()); c++ {
+		state.palette[c] = struct{}{}
+	}
+	station.SetCurrentData([]float64{float64(pickColor(station.GetRandom(), state.palette))})
+}
+
+// pickColor - returns random color from palette, colors are drawn in ascending order,
+// so the choice does not depend on map iteration order
+func pickColor(rng *rand.Rand, palette map[int]struct{}) int {
+	colors := make([]int, 0, len(palette))
+	for c := range palette {
+		colors = append(colors, c)
+	}
+	sort.Ints(colors)
+
+	return colors[rng.Intn(len(colors))]
+}
+
+func (ColoringProtocol) OnInitialize(station IStation, state *coloringState) {
+	station.Broadcast()
+}
+
+func (ColoringProtocol) OnDataReceive(station IStation, state *coloringState) {
+	mq := station.GetMsgQueue()
+	if station.GetRoundCounter()%2 == 0 {
+		// tentative colors of uncolored neighbours
+		color := station.GetCurrentData()[0]
+		conflict := false
+		for mq.Len() > 0 {
+			if mq.Dequeue().Data[0] == color {
+				conflict = true
+			}
+		}
+		if !state.colored && !conflict {
+			state.colored = true
+			state.justColored = true
+		}
+		return
+	}
+
+	// final colors of neighbours
+	for mq.Len() > 0 {
+		delete(state.palette, int(mq.Dequeue().Data[0]))
+	}
+}
+
+func (ColoringProtocol) OnDataPropagate(station IStation, state *coloringState) {
+	if station.GetRoundCounter()%2 == 0 {
+		if state.justColored {
+			state.justColored = false
+			station.SynchronizedBroadcast()
+		}
+		return
+	}
+
+	if !state.colored && len(state.palette) > 0 {
+		station.SetCurrentData([]float64{float64(pickColor(station.GetRandom(), state.palette))})
+		station.SynchronizedBroadcast()
+	}
+}
+
+func (p ColoringProtocol) StopCondition(station IStation, state *coloringState) bool {
+	return station.GetRoundCounter() < 2*randomizedPhases(p.nofPhases, station)
+}
+
+func (ColoringProtocol) OnFinalize(station IStation, state *coloringState) {
+	if state.colored {
+		station.SetResult(station.GetCurrentData()[0])
+	} else {
+		station.SetResult(-1)
+	}
+}
+
+func (ColoringProtocol) CalculateStationExactResult(station IStation, state *coloringState) float64 {
+	return -1
+}
+
+// CalculateGlobalExactResult - returns 1 if computed coloring is proper, 0 otherwise
+func (p ColoringProtocol) CalculateGlobalExactResult(stations *[]IStation) float64 {
+	report := p.checkValidity(stations)
+	return report.valid()
+}
+
+func (p ColoringProtocol) CalculateProtocolStats(stations *[]IStation) map[string]interface{} {
+	report := p.checkValidity(stations)
+	stats := report.stats()
+	colors := map[float64]struct{}{}
+	maxDegree := 0
+	for _, station := range *stations {
+		if StateOf[coloringState](station).colored {
+			colors[station.GetCurrentData()[0]] = struct{}{}
+		}
+		if d := station.GetGraph().GraphStructure.Degree(station.GetId()); d > maxDegree {
+			maxDegree = d
+		}
+	}
+	stats["nof_colors"] = len(colors)
+	stats["max_degree"] = maxDegree
+
+	return stats
+}
+
+// checkValidity - checks that every station is colored and adjacent stations (original topology) differ
+func (ColoringProtocol) checkValidity(stations *[]IStation) *validityReport {
+	report := &validityReport{}
+	colors := make([]float64, len(*stations))
+	for _, station := range *stations {
+		colors[station.GetId()] = -1
+		if StateOf[coloringState](station).colored {
+			colors[station.GetId()] = station.GetCurrentData()[0]
+		}
+	}
+
+	for v, color := range colors {
+		if color < 0 {
+			report.addViolation("uncolored", v)
+		}
+	}
+
+	for v, neighbours := range (*stations)[0].GetGraph().GetOriginalNeighbours() {
+		for _, w := range neighbours {
+			if w > v && colors[v] >= 0 && colors[v] == colors[w] {
+				report.addViolation("conflict", v, w)
+			}
+		}
+	}
+
+	return report
+}
@@ -0,0 +1,21 @@
+package simulation
+
+import "testing"
+
+func TestColoringIsProper(t *testing.T) {
+	for _, test := range misTestGraphs {
+		g := test.g()
+		result := runOnGraph(t, "coloring", "", g, test.diameter)
+		neighbours := g.GetOriginalNeighbours()
+		for v, station := range result.Stations {
+			if color := station.Result; color < 0 || int(color) > len(neighbours[v]) {
+				t.Errorf("%s: station %d has color %v outside palette of degree %d", test.name, v, color, len(neighbours[v]))
+			}
+			for _, w := range neighbours[v] {
+				if station.Result == result.Stations[w].Result {
+					t.Errorf("%s: adjacent stations %d and %d have color %v", test.name, v, w, station.Result)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package simulation
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ConsensusProtocol - iterative approximate agreement on real-valued inputs. Every round stations
+// broadcast (value, degree). In "average" variant values are updated with Metropolis weights
+// (converges to average of inputs), in "wmsr" variant up to f values larger and f values smaller
+// than own one are discarded (W-MSR) and the rest is averaged, which tolerates f Byzantine neighbours.
+// Byzantine stations send arbitrary values, chosen independently for every neighbour.
+type ConsensusProtocol struct {
+	wmsr       bool
+	f          int
+	byzantine  map[int]struct{}
+	attack     string
+	epsilon    float64
+	nofRounds  int
+	minInput   float64
+	maxInput   float64
+	extremeVal float64
+}
+
+// consensusState - state of station in approximate agreement
+type consensusState struct {
+	// history - value of station after every round
+	history []float64
+}
+
+func init() {
+	RegisterProtocol(ProtocolInfo{
+		Name:        "consensus",
+		Description: "reach approximate agreement using averaging or W-MSR (Byzantine tolerant) consensus",
+		Parameters: []ProtocolParameter{
+			{Name: "variant", Type: StringParameter, Default: "average", Description: "average|wmsr"},
+			{Name: "f", Type: IntParameter, Default: "1", Description: "number of tolerated Byzantine neighbours (wmsr)"},
+			{Name: "byzantine", Type: StringParameter, Default: "", Description: "ids of Byzantine stations separated by ';'"},
+			{Name: "attack", Type: StringParameter, Default: "random", Description: "behaviour of Byzantine stations (random|extreme)"},
+			{Name: "epsilon", Type: FloatParameter, Default: "0.001", Description: "agreement threshold"},
+			{Name: "rounds", Type: IntParameter, Default: "100", Description: "number of rounds"},
+			{Name: "min", Type: FloatParameter, Default: "0", Description: "minimal input value (uniform distribution)"},
+			{Name: "max", Type: FloatParameter, Default: "100", Description: "maximal input value"},
+		},
+		Factory: newConsensusProtocol,
+	})
+}
+
+func newConsensusProtocol(params ProtocolParams) (Protocol, error) {
+	p := ConsensusProtocol{
+		f:         params.Int("f"),
+		byzantine: map[int]struct{}{},
+		attack:    params.String("attack"),
+		epsilon:   params.Float("epsilon"),
+		nofRounds: params.Int("rounds"),
+		minInput:  params.Float("min"),
+		maxInput:  params.Float("max"),
+	}
+
+	switch params.String("variant") {
+	case "average":
+	case "wmsr":
+		p.wmsr = true
+	default:
+		return nil, fmt.Errorf("unknown consensus variant %q (average|wmsr)", params.String("variant"))
+	}
+	if p.attack != "random" && p.attack != "extreme" {
+		return nil, fmt.Errorf("unknown attack %q (random|extreme)", p.attack)
+	}
+	if p.f < 0 || p.epsilon <= 0 || p.nofRounds <= 0 || p.minInput > p.maxInput {
+		return nil, fmt.Errorf("f should be non-negative, epsilon and rounds positive and min not greater than max")
+	}
+
+	for _, s := range strings.Split(params.String("byzantine"), ";") {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		id, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil || id < 0 {
+			return nil, fmt.Errorf("invalid Byzantine station %q", s)
+		}
+		p.byzantine[id] = struct{}{}
+	}
+	// extreme attack pulls values far above the range of inputs
+	p.extremeVal = p.maxInput + 1000*(p.maxInput-p.minInput+1)
+
+	return WithState[consensusState](p), nil
+}
+
+func (p ConsensusProtocol) isByzantine(station IStation) bool {
+	_, ok := p.byzantine[station.GetId()]
+	return ok
+}
+
+func (p ConsensusProtocol) GetInitialData(station IStation, state *consensusState) {
+	value := p.minInput + station.GetRandom().Float64()*(p.maxInput-p.minInput)
+	station.ObserveValue([]float64{value})
+	station.SetCurrentData([]float64{value})
+	state.history = []float64{value}
+}
+
+func (p ConsensusProtocol) OnInitialize(station IStation, state *consensusState) {
+	p.OnDataPropagate(station, state)
+}
+
+func (p ConsensusProtocol) OnDataReceive(station IStation, state *consensusState) {
+	type neighbourValue struct {
+		value  float64
+		degree float64
+	}
+	received := make([]neighbourValue, 0)
+	mq := station.GetMsgQueue()
+	for mq.Len() > 0 {
+		msg := mq.Dequeue()
+		received = append(received, neighbourValue{msg.Data[0], msg.Data[1]})
+	}
+	if p.isByzantine(station) {
+		return
+	}
+
+	value := station.GetCurrentData()[0]
+	newValue := value
+	if p.wmsr {
+		sort.Slice(received, func(i, j int) bool { return received[i].value < received[j].value })
+		// discard up to f smallest values below own value and up to f largest above it
+		lo, hi := 0, len(received)
+		for lo < len(received) && lo < p.f && received[lo].value < value {
+			lo++
+		}
+		for hi > lo && len(received)-hi < p.f && received[hi-1].value > value {
+			hi--
+		}
+		sum := value
+		for _, r := range received[lo:hi] {
+			sum += r.value
+		}
+		newValue = sum / float64(1+hi-lo)
+	} else {
+		// Metropolis weights: 1 / (1 + max(deg(i), deg(j)))
+		degree := float64(len(station.GetNeighbours()))
+		for _, r := range received {
+			newValue += (r.value - value) / (1 + math.Max(degree, r.degree))
+		}
+	}
+
+	station.SetCurrentData([]float64{newValue})
+	state.history = append(state.history, newValue)
+}
+
+func (p ConsensusProtocol) OnDataPropagate(station IStation, state *consensusState) {
+	neighbours := station.GetNeighbours()
+	degree := float64(len(neighbours))
+	for _, w := range neighbours {
+		value := station.GetCurrentData()[0]
+		if p.isByzantine(station) {
+			value = p.extremeVal
+			if p.attack == "random" {
+				value = p.minInput + (station.GetRandom().Float64()*3-1)*(p.maxInput-p.minInput)
+			}
+		}
+		station.Send(w, []float64{value, degree})
+	}
+}
+
+func (p ConsensusProtocol) StopCondition(station IStation, state *consensusState) bool {
+	return station.GetRoundCounter() < p.nofRounds
+}
+
+func (ConsensusProtocol) OnFinalize(station IStation, state *consensusState) {
+	station.SetResult(station.GetCurrentData()[0])
+}
+
+func (ConsensusProtocol) CalculateStationExactResult(station IStation, state *consensusState) float64 {
+	return station.GetObservedValues()[0][0]
+}
+
+// CalculateGlobalExactResult - returns average of inputs of honest stations
+func (p ConsensusProtocol) CalculateGlobalExactResult(stations *[]IStation) float64 {
+	sum, count := 0., 0
+	for _, station := range *stations {
+		if !p.isByzantine(station) {
+			sum += station.GetObservedValues()[0][0]
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+
+	return sum / float64(count)
+}
+
+func (p ConsensusProtocol) CalculateProtocolStats(stations *[]IStation) map[string]interface{} {
+	minInput, maxInput := math.Inf(1), math.Inf(-1)
+	minPerRound := make([]float64, p.nofRounds+1)
+	maxPerRound := make([]float64, p.nofRounds+1)
+	for r := range minPerRound {
+		minPerRound[r], maxPerRound[r] = math.Inf(1), math.Inf(-1)
+	}
+
+	for _, station := range *stations {
+		if p.isByzantine(station) {
+			continue
+		}
+		input := station.GetObservedValues()[0][0]
+		minInput, maxInput = math.Min(minInput, input), math.Max(maxInput, input)
+		for r, v := range StateOf[consensusState](station).history {
+			minPerRound[r] = math.Min(minPerRound[r], v)
+			maxPerRound[r] = math.Max(maxPerRound[r], v)
+		}
+	}
+
+	spreadPerRound := make([]float64, 0, p.nofRounds+1)
+	roundsToAgreement := -1
+	for r := range minPerRound {
+		if math.IsInf(minPerRound[r], 1) {
+			break
+		}
+		spread := maxPerRound[r] - minPerRound[r]
+		spreadPerRound = append(spreadPerRound, spread)
+		if roundsToAgreement < 0 && spread <= p.epsilon {
+			roundsToAgreement = r
+		}
+	}
+	if len(spreadPerRound) == 0 {
+		return map[string]interface{}{"honest_stations": 0}
+	}
+
+	last := len(spreadPerRound) - 1
+	return map[string]interface{}{
+		"final_spread":                spreadPerRound[last],
+		"spread_per_round":            spreadPerRound,
+		"validity":                    minPerRound[last] >= minInput && maxPerRound[last] <= maxInput,
+		"rounds_to_epsilon_agreement": roundsToAgreement,
+		"nof_byzantine":               len(p.byzantine),
+	}
+}
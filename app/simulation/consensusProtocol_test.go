@@ -0,0 +1,67 @@
+package simulation
+
+import (
+	"app/simulationGraph"
+	"math"
+	"testing"
+)
+
+func TestAverageConsensusConvergesToMean(t *testing.T) {
+	tests := []struct {
+		name     string
+		g        *simulationGraph.GraphWrapper
+		diameter int
+	}{
+		{"path", simulationGraph.BuildPath(6, "", "0"), 5},
+		{"grid", simulationGraph.BuildGrid(4, 4, "", "0"), 6},
+		{"clique", simulationGraph.BuildClique(7, "", "0"), 1},
+	}
+
+	for _, test := range tests {
+		result := runOnGraph(t, "consensus", "rounds=300", test.g, test.diameter)
+		for _, station := range result.Stations {
+			if math.Abs(station.Result-result.Result) > 1e-3 {
+				t.Errorf("%s: station %d agreed on %v, mean of inputs is %v", test.name, station.GetId(), station.Result, result.Result)
+			}
+		}
+		if stats := result.ProtocolStats; stats["validity"] != true || stats["rounds_to_epsilon_agreement"].(int) < 0 {
+			t.Errorf("%s: validity %v, agreement after %v rounds", test.name, stats["validity"], stats["rounds_to_epsilon_agreement"])
+		}
+	}
+}
+
+func TestWmsrValidityWithByzantineStations(t *testing.T) {
+	tests := []struct {
+		name      string
+		g         *simulationGraph.GraphWrapper
+		diameter  int
+		f         string
+		byzantine string
+	}{
+		{"clique f=1", simulationGraph.BuildClique(6, "", "0"), 1, "1", "2"},
+		{"clique f=2", simulationGraph.BuildClique(10, "", "0"), 1, "2", "3;7"},
+		{"hypercube f=1", simulationGraph.BuildHyperCube(4, "", "0"), 4, "1", "5"},
+	}
+
+	for _, test := range tests {
+		for _, attack := range []string{"random", "extreme"} {
+			params := "variant=wmsr,rounds=60,f=" + test.f + ",byzantine=" + test.byzantine + ",attack=" + attack
+			result := runOnGraph(t, "consensus", params, test.g, test.diameter)
+			stats := result.ProtocolStats
+			if stats["validity"] != true {
+				t.Errorf("%s, %s: honest values left range of honest inputs", test.name, attack)
+			}
+			spread := stats["spread_per_round"].([]float64)
+			if final := stats["final_spread"].(float64); final > 1e-3*spread[0] {
+				t.Errorf("%s, %s: spread decreased only from %v to %v", test.name, attack, spread[0], final)
+			}
+		}
+	}
+
+	// without trimming a single extreme Byzantine station drags honest values out of range
+	g := simulationGraph.BuildClique(6, "", "0")
+	result := runOnGraph(t, "consensus", "rounds=60,byzantine=2,attack=extreme", g, 1)
+	if result.ProtocolStats["validity"] != false {
+		t.Errorf("average consensus kept validity under extreme attack")
+	}
+}
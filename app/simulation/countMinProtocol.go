@@ -0,0 +1,375 @@
+package simulation
+
+import (
+	"app/hashing"
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// CountMinProtocol - heavy hitters protocol based on Count-Min sketches,
+// "gossip" variant floods sketches merged by element-wise max (duplicate-insensitive),
+// "tree" variant sums sketches along BFS spanning tree (convergecast) and broadcasts top-k down the tree
+type CountMinProtocol struct {
+	variant        string
+	width          int
+	depth          int
+	k              int
+	nofCandidates  int
+	nofObservables int
+	universe       uint64
+	zipfS          float64
+	tree           treeAggregation
+}
+
+// countMinState - state of station in count-min heavy hitters protocol
+type countMinState struct {
+	sketchChanged bool
+	tree          treeState // tree variant
+	// topK - value, estimated frequency for each of top-k values
+	topK []float64
+}
+
+func init() {
+	RegisterProtocol(ProtocolInfo{
+		Name:        "countMin",
+		Description: "find top-k frequent observed values using Count-Min sketches",
+		Parameters: []ProtocolParameter{
+			{Name: "variant", Type: StringParameter, Default: "gossip", Description: "merge strategy (gossip|tree)"},
+			{Name: "width", Type: IntParameter, Default: "64", Description: "number of counters in a sketch row"},
+			{Name: "depth", Type: IntParameter, Default: "4", Description: "number of sketch rows (hash functions)"},
+			{Name: "k", Type: IntParameter, Default: "10", Description: "number of reported most frequent values"},
+			{Name: "candidates", Type: IntParameter, Default: "20", Description: "number of candidate values kept by station"},
+			{Name: "observations", Type: IntParameter, Default: "50", Description: "number of values observed by each station"},
+			{Name: "universe", Type: IntParameter, Default: "1000", Description: "observed values are drawn from [0, universe]"},
+			{Name: "s", Type: FloatParameter, Default: "1.5", Description: "Zipf distribution parameter (> 1)"},
+			{Name: "root", Type: IntParameter, Default: "0", Description: "root of spanning tree (tree variant)"},
+		},
+		Factory: newCountMinProtocol,
+	})
+}
+
+func newCountMinProtocol(params ProtocolParams) (Protocol, error) {
+	p := &CountMinProtocol{
+		variant:        params.String("variant"),
+		width:          params.Int("width"),
+		depth:          params.Int("depth"),
+		k:              params.Int("k"),
+		nofCandidates:  params.Int("candidates"),
+		nofObservables: params.Int("observations"),
+		universe:       uint64(params.Int("universe")),
+		zipfS:          params.Float("s"),
+	}
+
+	if p.variant != "gossip" && p.variant != "tree" {
+		return nil, fmt.Errorf("unknown count-min variant %q (gossip|tree)", p.variant)
+	}
+	if p.width <= 0 || p.depth <= 0 || p.k <= 0 || p.nofObservables <= 0 || p.universe == 0 {
+		return nil, fmt.Errorf("width, depth, k, observations and universe should be positive")
+	}
+	if p.nofCandidates < p.k {
+		return nil, fmt.Errorf("number of candidates (%d) should not be smaller than k (%d)", p.nofCandidates, p.k)
+	}
+	if p.zipfS <= 1 {
+		return nil, fmt.Errorf("zipf parameter s should be greater than 1, got %v", p.zipfS)
+	}
+
+	p.tree = treeAggregation{root: params.Int("root"), merge: p.sumMerge, finish: p.topKPayload}
+	return WithState[countMinState](p), nil
+}
+
+func (p *CountMinProtocol) GetInitialData(station IStation, state *countMinState) {
+	zipf := rand.NewZipf(rand.New(rand.NewSource(station.GetRandom().Int63())), p.zipfS, 1, p.universe)
+	cells := make([]float64, p.width*p.depth)
+	counts := map[float64]int{}
+	for i := 0; i < p.nofObservables; i++ {
+		value := float64(zipf.Uint64())
+		station.ObserveValue([]float64{value})
+		counts[value]++
+		for _, c := range p.cellsOf(value) {
+			cells[c]++
+		}
+	}
+
+	// local candidates - most frequent locally observed values
+	candidates := make([]float64, 0, len(counts))
+	for value := range counts {
+		candidates = append(candidates, value)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if counts[candidates[i]] != counts[candidates[j]] {
+			return counts[candidates[i]] > counts[candidates[j]]
+		}
+		return candidates[i] < candidates[j]
+	})
+	if len(candidates) > p.nofCandidates {
+		candidates = candidates[:p.nofCandidates]
+	}
+
+	station.SetCurrentData(append(cells, candidates...))
+}
+
+func (p *CountMinProtocol) OnInitialize(station IStation, state *countMinState) {
+	if p.variant == "tree" {
+		p.tree.initialize(station, &state.tree, station.GetCurrentData())
+		return
+	}
+
+	station.Broadcast()
+}
+
+func (p *CountMinProtocol) OnDataReceive(station IStation, state *countMinState) {
+	if p.variant == "tree" {
+		p.tree.receive(station, &state.tree)
+		return
+	}
+
+	state.sketchChanged = false
+	current := station.GetCurrentData()
+	mq := station.GetMsgQueue()
+	for mq.Len() > 0 {
+		msg := mq.Dequeue()
+		merged := p.maxMerge(current, msg.Data)
+		if !equalVectors(merged, current) {
+			state.sketchChanged = true
+			current = merged
+		}
+	}
+
+	if state.sketchChanged {
+		station.SetCurrentData(current)
+	}
+}
+
+func (p *CountMinProtocol) OnDataPropagate(station IStation, state *countMinState) {
+	if p.variant == "tree" {
+		p.tree.propagate(station, &state.tree)
+		return
+	}
+
+	if state.sketchChanged {
+		station.SynchronizedBroadcast()
+	}
+}
+
+func (p *CountMinProtocol) StopCondition(station IStation, state *countMinState) bool {
+	if p.variant == "tree" {
+		return station.GetRoundCounter() < treeAggregationRounds(station.GetGraph().GetDiameter())
+	}
+
+	return station.GetRoundCounter() < station.GetGraph().GetDiameter()
+}
+
+func (p *CountMinProtocol) OnFinalize(station IStation, state *countMinState) {
+	if p.variant == "tree" {
+		state.topK, _ = p.tree.result(&state.tree)
+	} else {
+		state.topK = p.topKPayload(station.GetCurrentData())
+	}
+
+	if len(state.topK) > 0 {
+		station.SetResult(state.topK[1])
+	}
+}
+
+func (p *CountMinProtocol) CalculateStationExactResult(station IStation, state *countMinState) float64 {
+	return -1
+}
+
+// CalculateGlobalExactResult - returns exact frequency of the most frequent value
+func (p *CountMinProtocol) CalculateGlobalExactResult(stations *[]IStation) float64 {
+	frequencies := exactFrequencies(stations)
+	top := topKValues(frequencies, 1)
+	if len(top) == 0 {
+		return 0
+	}
+
+	return float64(frequencies[top[0]])
+}
+
+func (p *CountMinProtocol) CalculateProtocolStats(stations *[]IStation) map[string]interface{} {
+	frequencies := exactFrequencies(stations)
+	exactTopK := topKValues(frequencies, p.k)
+	exactSet := map[float64]struct{}{}
+	exactTopKFrequencies := make([]int, 0, len(exactTopK))
+	for _, v := range exactTopK {
+		exactSet[v] = struct{}{}
+		exactTopKFrequencies = append(exactTopKFrequencies, frequencies[v])
+	}
+
+	sumPrecision, sumRecall, minRecall := 0., 0., 1.
+	withoutResult := 0
+	for _, station := range *stations {
+		topK := StateOf[countMinState](station).topK
+		if len(topK) == 0 {
+			withoutResult++
+		}
+
+		hits := 0
+		for i := 0; i < len(topK); i += 2 {
+			if _, ok := exactSet[topK[i]]; ok {
+				hits++
+			}
+		}
+
+		precision, recall := 0., 0.
+		if len(topK) > 0 {
+			precision = float64(hits) / float64(len(topK)/2)
+		}
+		if len(exactTopK) > 0 {
+			recall = float64(hits) / float64(len(exactTopK))
+		}
+		sumPrecision += precision
+		sumRecall += recall
+		if recall < minRecall {
+			minRecall = recall
+		}
+	}
+
+	nofStations := float64(len(*stations))
+	return map[string]interface{}{
+		"variant":                 p.variant,
+		"exact_top_k":             exactTopK,
+		"exact_top_k_frequencies": exactTopKFrequencies,
+		"avg_precision":           sumPrecision / nofStations,
+		"avg_recall":              sumRecall / nofStations,
+		"min_recall":              minRecall,
+		"stations_without_result": withoutResult,
+	}
+}
+
+// cellsOf - returns indices of counters (one in each row) for given value
+func (p *CountMinProtocol) cellsOf(value float64) []int {
+	cells := make([]int, p.depth)
+	for row, position := range hashing.Positions(valueToBytes(value), p.depth, uint64(p.width)) {
+		cells[row] = row*p.width + int(position)
+	}
+
+	return cells
+}
+
+// estimate - Count-Min estimate of value frequency
+func (p *CountMinProtocol) estimate(cells []float64, value float64) float64 {
+	min := -1.
+	for _, c := range p.cellsOf(value) {
+		if min < 0 || cells[c] < min {
+			min = cells[c]
+		}
+	}
+
+	return min
+}
+
+// mergeCandidates - returns union of candidates limited to most frequent ones according to cells
+func (p *CountMinProtocol) mergeCandidates(cells []float64, a, b []float64) []float64 {
+	set := map[float64]struct{}{}
+	candidates := make([]float64, 0, len(a)+len(b))
+	for _, v := range append(append([]float64{}, a...), b...) {
+		if _, ok := set[v]; !ok {
+			set[v] = struct{}{}
+			candidates = append(candidates, v)
+		}
+	}
+
+	estimates := map[float64]float64{}
+	for _, v := range candidates {
+		estimates[v] = p.estimate(cells, v)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if estimates[candidates[i]] != estimates[candidates[j]] {
+			return estimates[candidates[i]] > estimates[candidates[j]]
+		}
+		return candidates[i] < candidates[j]
+	})
+	if len(candidates) > p.nofCandidates {
+		candidates = candidates[:p.nofCandidates]
+	}
+
+	return candidates
+}
+
+// maxMerge - merges sketches by element-wise max (idempotent, safe for flooding)
+func (p *CountMinProtocol) maxMerge(a, b []float64) []float64 {
+	n := p.width * p.depth
+	cells := make([]float64, n)
+	for i := 0; i < n; i++ {
+		cells[i] = a[i]
+		if b[i] > cells[i] {
+			cells[i] = b[i]
+		}
+	}
+
+	return append(cells, p.mergeCandidates(cells, a[n:], b[n:])...)
+}
+
+// sumMerge - merges sketches by element-wise sum (each sketch has to be merged exactly once)
+func (p *CountMinProtocol) sumMerge(a, b []float64) []float64 {
+	n := p.width * p.depth
+	cells := make([]float64, n)
+	for i := 0; i < n; i++ {
+		cells[i] = a[i] + b[i]
+	}
+
+	return append(cells, p.mergeCandidates(cells, a[n:], b[n:])...)
+}
+
+// topKPayload - returns (value, estimated frequency) pairs of k most frequent candidates
+func (p *CountMinProtocol) topKPayload(sketch []float64) []float64 {
+	n := p.width * p.depth
+	cells := sketch[:n]
+	candidates := p.mergeCandidates(cells, sketch[n:], nil)
+	if len(candidates) > p.k {
+		candidates = candidates[:p.k]
+	}
+
+	payload := make([]float64, 0, 2*len(candidates))
+	for _, v := range candidates {
+		payload = append(payload, v, p.estimate(cells, v))
+	}
+
+	return payload
+}
+
+// exactFrequencies - counts observed values of all stations
+func exactFrequencies(stations *[]IStation) map[float64]int {
+	frequencies := map[float64]int{}
+	for _, station := range *stations {
+		for _, v := range station.GetObservedValues() {
+			frequencies[v[0]]++
+		}
+	}
+
+	return frequencies
+}
+
+// topKValues - returns k most frequent values (ties broken by smaller value)
+func topKValues(frequencies map[float64]int, k int) []float64 {
+	values := make([]float64, 0, len(frequencies))
+	for v := range frequencies {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if frequencies[values[i]] != frequencies[values[j]] {
+			return frequencies[values[i]] > frequencies[values[j]]
+		}
+		return values[i] < values[j]
+	})
+	if len(values) > k {
+		values = values[:k]
+	}
+
+	return values
+}
+
+func equalVectors(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
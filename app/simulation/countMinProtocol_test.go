@@ -0,0 +1,74 @@
+package simulation
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// countMinSketch - returns cells of sketch counting given values
+func countMinSketch(p *CountMinProtocol, values []float64) []float64 {
+	cells := make([]float64, p.width*p.depth)
+	for _, value := range values {
+		for _, c := range p.cellsOf(value) {
+			cells[c]++
+		}
+	}
+
+	return cells
+}
+
+func TestCountMinErrorBound(t *testing.T) {
+	tests := []struct {
+		width int
+		depth int
+	}{
+		{width: 64, depth: 4},
+		{width: 272, depth: 5},
+	}
+
+	for _, test := range tests {
+		p := &CountMinProtocol{width: test.width, depth: test.depth}
+		zipf := rand.NewZipf(rand.New(rand.NewSource(1)), 1.2, 1, 5000)
+		values := make([]float64, 20000)
+		frequencies := map[float64]int{}
+		for i := range values {
+			values[i] = float64(zipf.Uint64())
+			frequencies[values[i]]++
+		}
+		cells := countMinSketch(p, values)
+
+		// estimate exceeds frequency by more than e/width * n with probability at most e^-depth
+		bound := math.E / float64(test.width) * float64(len(values))
+		exceeded := 0
+		for value, frequency := range frequencies {
+			estimate := p.estimate(cells, value)
+			if estimate < float64(frequency) {
+				t.Fatalf("width=%d, depth=%d: estimate %v of value %v is smaller than frequency %d",
+					test.width, test.depth, estimate, value, frequency)
+			}
+			if estimate-float64(frequency) > bound {
+				exceeded++
+			}
+		}
+
+		if ratio := float64(exceeded) / float64(len(frequencies)); ratio > 2*math.Exp(-float64(test.depth)) {
+			t.Errorf("width=%d, depth=%d: error bound exceeded for %.4f of values", test.width, test.depth, ratio)
+		}
+	}
+}
+
+func TestCountMinSumMergeIsLinear(t *testing.T) {
+	p := &CountMinProtocol{width: 32, depth: 3, nofCandidates: 4}
+	a, b := []float64{1, 2, 2, 5}, []float64{2, 7, 7, 7}
+	// values 2 and 7 are observed three times, ties are ordered by value
+	merged := p.sumMerge(append(countMinSketch(p, a), 1, 2), append(countMinSketch(p, b), 7))
+	expected := countMinSketch(p, append(append([]float64{}, a...), b...))
+
+	if !equalVectors(merged[:len(expected)], expected) {
+		t.Errorf("merged sketch differs from sketch of all values")
+	}
+	if candidates := merged[len(expected):]; !equalVectors(candidates, []float64{2, 7, 1}) {
+		t.Errorf("expected candidates [2 7 1], got %v", candidates)
+	}
+}
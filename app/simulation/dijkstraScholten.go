@@ -0,0 +1,130 @@
+package simulation
+
+const (
+	dsBasicFlag     = 1
+	dsTerminateFlag = 2
+)
+
+// dijkstraScholten - helper used by protocols detecting termination of diffusing computation started
+// by root (Dijkstra, Scholten). Every basic message is acknowledged, station engaged by first received
+// basic message acknowledges it when it becomes passive (sends no basic message in a round) and all its
+// messages were acknowledged. Root detects termination when it is passive and its deficit drops to zero,
+// then termination message is flooded. Acknowledgements and termination are piggybacked, so at most one
+// message per neighbour is sent in a round: [flags, nofAcks, payload...].
+// Detection requires reliable links, lost acknowledgement blocks it.
+type dijkstraScholten struct {
+	root int
+}
+
+// dsState - state of a station in termination detection (part of state of protocol using the helper)
+type dsState struct {
+	engaged     bool
+	parent      int // -1 for root
+	deficit     int // number of sent basic messages which are not acknowledged yet
+	pendingAcks map[int]int
+	outgoing    map[int][]float64 // basic payloads queued in current round
+	terminated  bool
+	forwarded   bool // termination was forwarded to neighbours
+	// detectionRound - round in which station learned about termination
+	detectionRound int
+	nofControlMsgs int // messages without basic payload
+}
+
+// dsMessage - basic message received by station
+type dsMessage struct {
+	senderId int
+	payload  []float64
+}
+
+// initialize - sets up station state, root is engaged from the beginning
+func (d dijkstraScholten) initialize(station IStation, state *dsState) {
+	*state = dsState{
+		engaged:        station.GetId() == d.root,
+		parent:         -1,
+		pendingAcks:    map[int]int{},
+		outgoing:       map[int][]float64{},
+		detectionRound: -1,
+	}
+}
+
+// receive - processes acknowledgements and termination messages, returns basic messages
+func (d dijkstraScholten) receive(station IStation, state *dsState) []dsMessage {
+	basic := make([]dsMessage, 0)
+	mq := station.GetMsgQueue()
+	for mq.Len() > 0 {
+		msg := mq.Dequeue()
+		flags := int(msg.Data[0])
+		state.deficit -= int(msg.Data[1])
+		if flags&dsTerminateFlag != 0 && !state.terminated {
+			state.terminated = true
+			state.detectionRound = station.GetRoundCounter() + 1
+		}
+		if flags&dsBasicFlag == 0 {
+			continue
+		}
+
+		if state.engaged {
+			state.pendingAcks[msg.SenderId]++
+		} else {
+			state.engaged = true
+			state.parent = msg.SenderId
+		}
+		basic = append(basic, dsMessage{msg.SenderId, msg.Data[2:]})
+	}
+
+	return basic
+}
+
+// send - queues basic payload for neighbour, it is sent by flush
+func (d dijkstraScholten) send(state *dsState, receiverId int, payload []float64) {
+	state.outgoing[receiverId] = payload
+}
+
+// flush - sends queued basic messages, acknowledgements and termination, must be called in every propagation
+func (d dijkstraScholten) flush(station IStation, state *dsState) {
+	if state.engaged && state.deficit == 0 && len(state.outgoing) == 0 {
+		// passive station with all messages acknowledged leaves the tree
+		state.engaged = false
+		if station.GetId() == d.root {
+			state.terminated = true
+			state.detectionRound = station.GetRoundCounter()
+		} else {
+			state.pendingAcks[state.parent]++
+		}
+	}
+
+	forward := state.terminated && !state.forwarded
+	for _, w := range station.GetNeighbours() {
+		flags := 0.
+		payload, hasPayload := state.outgoing[w]
+		if hasPayload {
+			flags += dsBasicFlag
+		}
+		if forward {
+			flags += dsTerminateFlag
+		}
+		acks := state.pendingAcks[w]
+		if flags == 0 && acks == 0 {
+			continue
+		}
+
+		if !station.Send(w, append([]float64{flags, float64(acks)}, payload...)) {
+			// acknowledgements are resent when link is restored, basic message is lost
+			continue
+		}
+		delete(state.pendingAcks, w)
+		if hasPayload {
+			state.deficit++
+		} else {
+			state.nofControlMsgs++
+		}
+	}
+
+	state.forwarded = state.forwarded || forward
+	state.outgoing = map[int][]float64{}
+}
+
+// finished - returns true if station knows about termination and forwarded it to neighbours
+func (d dijkstraScholten) finished(state *dsState) bool {
+	return state.terminated && state.forwarded
+}
@@ -6,42 +6,32 @@ import (
 )
 
 type edgeRemover struct {
-	g                       *simulationGraph.GraphWrapper
-	edgeUpdateBeginChannel  chan bool
-	edgeUpdateFinishChannel chan bool
+	g           *simulationGraph.GraphWrapper
+	edges       [][2]int
+	rng         *rand.Rand
+	reliability linkReliability
 }
 
-func newEdgeRemover(g *simulationGraph.GraphWrapper, edgeUpdateBeginChannel chan bool, edgeUpdateFinishChannel chan bool) *edgeRemover {
-	return &edgeRemover{g: g,
-		edgeUpdateBeginChannel:  edgeUpdateBeginChannel,
-		edgeUpdateFinishChannel: edgeUpdateFinishChannel}
+func newEdgeRemover(g *simulationGraph.GraphWrapper, rng *rand.Rand, reliability linkReliability) *edgeRemover {
+	return &edgeRemover{g: g, edges: sortedEdges(g), rng: rng, reliability: reliability}
 }
 
-func (this *edgeRemover) RunEdgeUpdating(done chan bool) {
-	nofVertices := this.g.GraphStructure.Order()
-	edges := this.g.GetEdges()
-	relMap := this.g.GetRelMap()
-	for {
-		for i := 0; i < nofVertices; i++ {
-			select {
-			case <-this.edgeUpdateBeginChannel:
-				continue
-			case <-done:
-				return
-			}
+func (this *edgeRemover) UpdateEdges() []EdgeChange {
+	changes := make([]EdgeChange, 0)
+	this.reliability.nextRound()
+	for _, e := range this.edges {
+		v, w := e[0], e[1]
+		randVal := this.rng.Float64()
+		if randVal < this.reliability.probability(v, w) && this.g.GraphStructure.Edge(v, w) {
+			this.g.GraphStructure.DeleteBoth(v, w)
+			changes = append(changes, EdgeChange{V: v, W: w, Removed: true})
 		}
+	}
 
-		for v, e := range edges {
-			for w, _ := range e {
-				randVal := rand.Float64()
-				if randVal < relMap[v][w] && this.g.GraphStructure.Edge(v, w) {
-					this.g.GraphStructure.DeleteBoth(v, w)
-				}
-			}
-		}
+	return changes
+}
 
-		for i := 0; i < nofVertices; i++ {
-			this.edgeUpdateFinishChannel <- true
-		}
-	}
+// CalculateReliabilityStats - returns statistics of time-varying reliability expression (nil if probabilities are static)
+func (this *edgeRemover) CalculateReliabilityStats() map[string]interface{} {
+	return expressionStats(this.reliability)
 }
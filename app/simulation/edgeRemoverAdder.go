@@ -6,46 +6,37 @@ import (
 )
 
 type edgeRemoverAdder struct {
-	g                       *simulationGraph.GraphWrapper
-	edgeUpdateBeginChannel  chan bool
-	edgeUpdateFinishChannel chan bool
+	g           *simulationGraph.GraphWrapper
+	edges       [][2]int
+	rng         *rand.Rand
+	reliability linkReliability
 }
 
-func newEdgeRemoverAdder(g *simulationGraph.GraphWrapper, edgeUpdateBeginChannel chan bool, edgeUpdateFinishChannel chan bool) *edgeRemoverAdder {
-	return &edgeRemoverAdder{g: g,
-		edgeUpdateBeginChannel:  edgeUpdateBeginChannel,
-		edgeUpdateFinishChannel: edgeUpdateFinishChannel}
+func newEdgeRemoverAdder(g *simulationGraph.GraphWrapper, rng *rand.Rand, reliability linkReliability) *edgeRemoverAdder {
+	return &edgeRemoverAdder{g: g, edges: sortedEdges(g), rng: rng, reliability: reliability}
 }
 
-func (this *edgeRemoverAdder) RunEdgeUpdating(done chan bool) {
-	nofVertices := this.g.GraphStructure.Order()
-	edges := this.g.GetEdges()
-	relMap := this.g.GetRelMap()
-	for {
-		for i := 0; i < nofVertices; i++ {
-			select {
-			case <-this.edgeUpdateBeginChannel:
-				continue
-			case <-done:
-				return
-			}
+func (this *edgeRemoverAdder) UpdateEdges() []EdgeChange {
+	changes := make([]EdgeChange, 0)
+	this.reliability.nextRound()
+	for _, e := range this.edges {
+		v, w := e[0], e[1]
+		randVal := this.rng.Float64()
+		p := this.reliability.probability(v, w)
+		q := 1 - p
+		if randVal < p && this.g.GraphStructure.Edge(v, w) {
+			this.g.GraphStructure.DeleteBoth(v, w)
+			changes = append(changes, EdgeChange{V: v, W: w, Removed: true})
+		} else if randVal < q && !this.g.GraphStructure.Edge(v, w) {
+			this.g.GraphStructure.AddBoth(v, w)
+			changes = append(changes, EdgeChange{V: v, W: w, Removed: false})
 		}
+	}
 
-		for v, e := range edges {
-			for w, _ := range e {
-				randVal := rand.Float64()
-				p := relMap[v][w]
-				q := 1 - p
-				if randVal < p && this.g.GraphStructure.Edge(v, w) {
-					this.g.GraphStructure.DeleteBoth(v, w)
-				} else if randVal < q && !this.g.GraphStructure.Edge(v, w) {
-					this.g.GraphStructure.AddBoth(v, w)
-				}
-			}
-		}
+	return changes
+}
 
-		for i := 0; i < nofVertices; i++ {
-			this.edgeUpdateFinishChannel <- true
-		}
-	}
+// CalculateReliabilityStats - returns statistics of time-varying reliability expression (nil if probabilities are static)
+func (this *edgeRemoverAdder) CalculateReliabilityStats() map[string]interface{} {
+	return expressionStats(this.reliability)
 }
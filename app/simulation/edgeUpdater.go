@@ -1,7 +1,179 @@
 package simulation
 
+import (
+	"app/simulationGraph"
+	"sort"
+)
+
 // IEdgeUpdater - interface used for reliability models
 type IEdgeUpdater interface {
-	// RunEdgeUpdating - runs edge updating task accordingly to chosen reliability model
-	RunEdgeUpdating(update chan bool)
+	// UpdateEdges - performs edge updates of a single round, returns changed edges
+	UpdateEdges() []EdgeChange
+}
+
+// ReliabilityStatsProvider - optional interface for edge updaters reporting statistics of links
+type ReliabilityStatsProvider interface {
+	// CalculateReliabilityStats - returns reliability model statistics (saved as reliability_stats)
+	CalculateReliabilityStats() map[string]interface{}
+}
+
+// statefulUpdater - edge updater with state saved in checkpoints
+type statefulUpdater interface {
+	IEdgeUpdater
+	// saveState - returns state of updater (its type has to be registered for snapshots)
+	saveState() interface{}
+	// restoreState - sets state returned by saveState
+	restoreState(state interface{})
+}
+
+// topologyCounter - optional interface for edge updaters collecting statistics of actual topology,
+// countTopology is called after all updates of a round, so changes made by later updaters (e.g. scenario) are seen
+type topologyCounter interface {
+	countTopology()
+}
+
+// updateEdges - performs edge updates of a single round and lets updater count resulting topology
+func updateEdges(updater IEdgeUpdater) []EdgeChange {
+	changes := updater.UpdateEdges()
+	if counter, ok := updater.(topologyCounter); ok {
+		counter.countTopology()
+	}
+	return changes
+}
+
+// EdgeChange - edge removed from or added to the graph
+type EdgeChange struct {
+	V       int  `json:"v"`
+	W       int  `json:"w"`
+	Removed bool `json:"removed"`
+}
+
+// compositeUpdater - applies updates of several edge updaters in order and reports net changes of a round,
+// e.g. edge added by reliability model and removed again by scenario is not reported
+type compositeUpdater struct {
+	updaters []IEdgeUpdater
+}
+
+func init() {
+	registerSnapshotType([]interface{}{})
+}
+
+func (this compositeUpdater) UpdateEdges() []EdgeChange {
+	order := make([][2]int, 0)
+	last := map[[2]int]EdgeChange{}
+	count := map[[2]int]int{}
+	for _, updater := range this.updaters {
+		for _, c := range updater.UpdateEdges() {
+			key := edgeKey(c.V, c.W)
+			if count[key] == 0 {
+				order = append(order, key)
+			}
+			count[key]++
+			last[key] = c
+		}
+	}
+
+	// changes of the same edge alternate, even number of them cancels out
+	changes := make([]EdgeChange, 0)
+	for _, key := range order {
+		if count[key]%2 == 1 {
+			changes = append(changes, last[key])
+		}
+	}
+	return changes
+}
+
+func (this compositeUpdater) countTopology() {
+	for _, updater := range this.updaters {
+		if counter, ok := updater.(topologyCounter); ok {
+			counter.countTopology()
+		}
+	}
+}
+
+// CalculateReliabilityStats - returns statistics of the first updater which provides them
+func (this compositeUpdater) CalculateReliabilityStats() map[string]interface{} {
+	for _, updater := range this.updaters {
+		if statsProvider, ok := updater.(ReliabilityStatsProvider); ok {
+			return statsProvider.CalculateReliabilityStats()
+		}
+	}
+	return nil
+}
+
+func (this compositeUpdater) saveState() interface{} {
+	states := make([]interface{}, len(this.updaters))
+	for i, updater := range this.updaters {
+		if u, ok := updater.(statefulUpdater); ok {
+			states[i] = u.saveState()
+		}
+	}
+	return states
+}
+
+func (this compositeUpdater) restoreState(state interface{}) {
+	for i, s := range state.([]interface{}) {
+		if u, ok := this.updaters[i].(statefulUpdater); ok && s != nil {
+			u.restoreState(s)
+		}
+	}
+}
+
+// sortedEdges - returns original edges of graph in deterministic order,
+// so updater with seeded random generator makes the same changes in every run
+func sortedEdges(g *simulationGraph.GraphWrapper) [][2]int {
+	edges := make([][2]int, 0)
+	for v, e := range g.GetEdges() {
+		for w := range e {
+			edges = append(edges, [2]int{v, w})
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i][0] != edges[j][0] {
+			return edges[i][0] < edges[j][0]
+		}
+		return edges[i][1] < edges[j][1]
+	})
+
+	return edges
+}
+
+// runEdgeUpdating - runs edge updating task for goroutine engine, edges are updated once per round
+// when all active stations asked for update, onUpdate is called with changes before stations continue
+func runEdgeUpdating(updater IEdgeUpdater, nofStations int, begin chan bool, finish chan bool, done chan bool,
+	onUpdate func(changes []EdgeChange)) {
+	nofActiveStations := nofStations
+	for {
+		nofWaiting := waitForStations(begin, done, &nofActiveStations)
+		if nofWaiting == 0 {
+			return
+		}
+
+		onUpdate(updateEdges(updater))
+
+		for i := 0; i < nofWaiting; i++ {
+			finish <- true
+		}
+	}
+}
+
+// waitForStations - waits until every active station asks for edge update (sends true on begin channel)
+// or announces that it stopped running protocol (sends false), returns number of stations waiting
+// for update, 0 means that all stations stopped or updating was cancelled
+func waitForStations(begin chan bool, done chan bool, nofActiveStations *int) int {
+	nofWaiting := 0
+	for i, n := 0, *nofActiveStations; i < n; i++ {
+		select {
+		case running := <-begin:
+			if running {
+				nofWaiting++
+			} else {
+				*nofActiveStations--
+			}
+		case <-done:
+			return 0
+		}
+	}
+
+	return nofWaiting
 }
@@ -0,0 +1,51 @@
+package simulation
+
+import (
+	"app/simulationGraph"
+	"testing"
+)
+
+// runWithEngine - runs protocol with default parameters on grid using given engine
+func runWithEngine(t *testing.T, name string, reliabilityModel string, engine string, nofWorkers int) JsonStatsStructure {
+	t.Helper()
+	p, err := NewProtocol(name, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := simulationGraph.BuildGrid(5, 5, reliabilityModel, "0.9")
+	g.SetDiameter(8)
+	manager := NewManager(reliabilityModel, g)
+	manager.SetSeed(42)
+	manager.SetTermination(Termination{Mode: TerminationByProtocol})
+	if err := manager.SetEngine(engine, nofWorkers); err != nil {
+		t.Fatal(err)
+	}
+
+	return manager.RunSimulation(p)
+}
+
+func TestEnginesGiveEqualStats(t *testing.T) {
+	engines := []struct {
+		engine     string
+		nofWorkers int
+	}{
+		{SequentialEngine, 4},
+		{GoroutineEngine, 1},
+	}
+
+	for _, model := range []string{"", "edge-remover", "gilbert-elliott"} {
+		for _, info := range GetRegisteredProtocols() {
+			if info.Name == "hopDistance" && model != "" {
+				// termination detection requires reliable links
+				continue
+			}
+			expected := runWithEngine(t, info.Name, model, SequentialEngine, 1)
+			for _, e := range engines {
+				result := runWithEngine(t, info.Name, model, e.engine, e.nofWorkers)
+				if diff := DiffStats(expected, result); diff != "" {
+					t.Errorf("%s (%q), %s engine with %d workers: %s", info.Name, model, e.engine, e.nofWorkers, diff)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package simulation
+
+import (
+	"app/simulationGraph"
+	"sync"
+)
+
+// exactValues - exact values of stations computed centrally on graph, shared by protocols whose exact
+// station results need computation over whole graph, values are computed once for graph of the last run
+type exactValues struct {
+	mutex   sync.Mutex
+	compute func(g *simulationGraph.GraphWrapper) []float64
+	g       *simulationGraph.GraphWrapper
+	values  []float64
+}
+
+func newExactValues(compute func(g *simulationGraph.GraphWrapper) []float64) *exactValues {
+	return &exactValues{compute: compute}
+}
+
+// of - returns values computed for given graph
+func (e *exactValues) of(g *simulationGraph.GraphWrapper) []float64 {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.g != g {
+		e.values = e.compute(g)
+		e.g = g
+	}
+	return e.values
+}
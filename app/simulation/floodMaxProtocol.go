@@ -0,0 +1,138 @@
+package simulation
+
+import (
+	"fmt"
+)
+
+// FloodMaxProtocol - leader election protocol, station with the highest uid becomes leader,
+// "basic" variant sends current maximum every round, "optimized" variant only when it improves
+type FloodMaxProtocol struct {
+	optimized bool
+}
+
+// floodMaxState - state of station in FloodMax
+type floodMaxState struct {
+	leaderChanged bool
+	// leaderRound - round in which station learned about its current leader
+	leaderRound int
+}
+
+func init() {
+	RegisterProtocol(ProtocolInfo{
+		Name:        "floodMax",
+		Description: "elect leader with the highest uid using FloodMax",
+		Parameters: []ProtocolParameter{
+			{Name: "variant", Type: StringParameter, Default: "basic", Description: "basic|optimized (forward only improvements)"},
+		},
+		Factory: func(params ProtocolParams) (Protocol, error) {
+			variant := params.String("variant")
+			if variant != "basic" && variant != "optimized" {
+				return nil, fmt.Errorf("unknown floodMax variant %q (basic|optimized)", variant)
+			}
+
+			return WithState[floodMaxState](FloodMaxProtocol{optimized: variant == "optimized"}), nil
+		},
+	})
+}
+
+func (FloodMaxProtocol) GetInitialData(station IStation, state *floodMaxState) {
+	// uids are random integers exactly representable as float64
+	uid := float64(station.GetRandom().Int63n(1 << 53))
+	station.ObserveValue([]float64{uid})
+	station.SetCurrentData([]float64{uid})
+}
+
+func (FloodMaxProtocol) OnInitialize(station IStation, state *floodMaxState) {
+	station.Broadcast()
+}
+
+func (FloodMaxProtocol) OnDataReceive(station IStation, state *floodMaxState) {
+	leader := station.GetCurrentData()[0]
+	state.leaderChanged = false
+	mq := station.GetMsgQueue()
+	for mq.Len() > 0 {
+		msg := mq.Dequeue()
+		if msg.Data[0] > leader {
+			leader = msg.Data[0]
+			state.leaderChanged = true
+			// round 0 is initialization, messages received in i-th iteration belong to round i+1
+			state.leaderRound = station.GetRoundCounter() + 1
+		}
+	}
+
+	if state.leaderChanged {
+		station.SetCurrentData([]float64{leader})
+	}
+}
+
+func (p FloodMaxProtocol) OnDataPropagate(station IStation, state *floodMaxState) {
+	if !p.optimized || state.leaderChanged {
+		station.SynchronizedBroadcast()
+	}
+}
+
+func (FloodMaxProtocol) StateChanged(station IStation, state *floodMaxState) bool {
+	return state.leaderChanged
+}
+
+func (FloodMaxProtocol) StopCondition(station IStation, state *floodMaxState) bool {
+	return station.GetRoundCounter() < station.GetGraph().GetDiameter()
+}
+
+func (FloodMaxProtocol) OnFinalize(station IStation, state *floodMaxState) {
+	station.SetResult(station.GetCurrentData()[0])
+}
+
+func (FloodMaxProtocol) CalculateStationExactResult(station IStation, state *floodMaxState) float64 {
+	return -1
+}
+
+func (FloodMaxProtocol) CalculateGlobalExactResult(stations *[]IStation) float64 {
+	leader := -1.
+	for _, station := range *stations {
+		if uid := station.GetObservedValues()[0][0]; uid > leader {
+			leader = uid
+		}
+	}
+
+	return leader
+}
+
+func (p FloodMaxProtocol) CalculateProtocolStats(stations *[]IStation) map[string]interface{} {
+	leader := p.CalculateGlobalExactResult(stations)
+	wrongLeader := 0
+	roundsToAgreement := 0
+	sentMsgs := 0
+	for _, station := range *stations {
+		if station.GetCurrentData()[0] != leader {
+			wrongLeader++
+		}
+		if round := StateOf[floodMaxState](station).leaderRound; round > roundsToAgreement {
+			roundsToAgreement = round
+		}
+		sentMsgs += station.GetSentMsgCounter()
+	}
+	if wrongLeader > 0 {
+		roundsToAgreement = -1
+	}
+
+	g := (*stations)[0].GetGraph()
+	nofEdges := 0
+	for _, e := range g.GetEdges() {
+		nofEdges += len(e)
+	}
+	// every round each station sends message over each of its links: 2|E| messages per round
+	theoreticalMsgs := 2 * g.GetDiameter() * nofEdges
+	ratio := 0.
+	if theoreticalMsgs > 0 {
+		ratio = float64(sentMsgs) / float64(theoreticalMsgs)
+	}
+
+	return map[string]interface{}{
+		"leader":                     leader,
+		"stations_with_wrong_leader": wrongLeader,
+		"rounds_to_agreement":        roundsToAgreement,
+		"theoretical_msgs":           theoreticalMsgs,
+		"msgs_to_theoretical_ratio":  ratio,
+	}
+}
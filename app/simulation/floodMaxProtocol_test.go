@@ -0,0 +1,39 @@
+package simulation
+
+import (
+	"app/simulationGraph"
+	"testing"
+)
+
+func TestFloodMaxElectsHighestUid(t *testing.T) {
+	tests := []struct {
+		name     string
+		g        *simulationGraph.GraphWrapper
+		diameter int
+	}{
+		{"path", simulationGraph.BuildPath(8, "", "0"), 7},
+		{"grid", simulationGraph.BuildGrid(4, 4, "", "0"), 6},
+		{"clique", simulationGraph.BuildClique(5, "", "0"), 1},
+	}
+
+	for _, test := range tests {
+		sentMsgs := map[string]int{}
+		for _, variant := range []string{"basic", "optimized"} {
+			result := runOnGraph(t, "floodMax", "variant="+variant, test.g, test.diameter)
+			for _, station := range result.Stations {
+				if station.Result != result.Result {
+					t.Errorf("%s, %s: station %d elected %v, highest uid is %v",
+						test.name, variant, station.GetId(), station.Result, result.Result)
+				}
+			}
+			if rounds := result.ProtocolStats["rounds_to_agreement"].(int); rounds < 0 || rounds > test.diameter {
+				t.Errorf("%s, %s: agreement in round %d, diameter is %d", test.name, variant, rounds, test.diameter)
+			}
+			sentMsgs[variant] = result.AllSentMsgs
+		}
+
+		if sentMsgs["optimized"] > sentMsgs["basic"] {
+			t.Errorf("%s: optimized variant sent %d messages, basic %d", test.name, sentMsgs["optimized"], sentMsgs["basic"])
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package simulation
+
+import (
+	"app/simulationGraph"
+	"github.com/montanaflynn/stats"
+	"math/rand"
+)
+
+// gilbertElliott - reliability model in which every link is a two-state Markov chain: link in good state
+// is present in graph, in bad state it is removed, good link fails with probability p_gb and bad link
+// recovers with probability p_bg in every round, so failures come in bursts of mean length 1/p_bg.
+// Links without p_gb and p_bg in graph file use p_gb = p and p_bg = 1-p (independent failures).
+// Uptime statistics are counted from actual topology after all updates of a round, so links removed
+// or blocked by scenario are counted as down even if they are in good state
+type gilbertElliott struct {
+	g           *simulationGraph.GraphWrapper
+	edges       [][2]int
+	transitions []*simulationGraph.LinkTransition // nil for links without transitions in graph file
+	rng         *rand.Rand
+	reliability linkReliability
+	state       *gilbertElliottState
+}
+
+// gilbertElliottState - states of links and counters used for uptime statistics (saved in checkpoints)
+type gilbertElliottState struct {
+	// Bad - states of links in Markov chain
+	Bad []bool
+	// Down - links missing in graph after last round, UpRounds and Failures count rounds in which link
+	// was present in graph and its transitions from present to missing
+	Down     []bool
+	UpRounds []int
+	Failures []int
+	Rounds   int
+}
+
+// linkUptime - statistics of a single link
+type linkUptime struct {
+	V               int     `json:"v"`
+	W               int     `json:"w"`
+	Uptime          float64 `json:"uptime"`
+	ExpectedUptime  float64 `json:"expected_uptime"`
+	NofFailures     int     `json:"nof_failures"`
+	MeanBurstLength float64 `json:"mean_burst_length"`
+}
+
+func init() {
+	registerSnapshotType(&gilbertElliottState{})
+}
+
+func newGilbertElliott(g *simulationGraph.GraphWrapper, rng *rand.Rand, reliability linkReliability) *gilbertElliott {
+	edges := sortedEdges(g)
+	transitions := make([]*simulationGraph.LinkTransition, len(edges))
+	for i, e := range edges {
+		if t, ok := g.GetLinkTransition(e[0], e[1]); ok {
+			transitions[i] = &t
+		}
+	}
+
+	return &gilbertElliott{g: g, edges: edges, transitions: transitions, rng: rng, reliability: reliability,
+		state: &gilbertElliottState{
+			Bad:      make([]bool, len(edges)),
+			Down:     make([]bool, len(edges)),
+			UpRounds: make([]int, len(edges)),
+			Failures: make([]int, len(edges)),
+		}}
+}
+
+func (this *gilbertElliott) UpdateEdges() []EdgeChange {
+	changes := make([]EdgeChange, 0)
+	this.reliability.nextRound()
+	for i, e := range this.edges {
+		v, w := e[0], e[1]
+		randVal := this.rng.Float64()
+		t := this.transition(i)
+		if !this.state.Bad[i] && randVal < t.GoodToBad {
+			this.state.Bad[i] = true
+			if this.g.GraphStructure.Edge(v, w) {
+				this.g.GraphStructure.DeleteBoth(v, w)
+				changes = append(changes, EdgeChange{V: v, W: w, Removed: true})
+			}
+		} else if this.state.Bad[i] && randVal < t.BadToGood {
+			this.state.Bad[i] = false
+			if !this.g.GraphStructure.Edge(v, w) {
+				this.g.GraphStructure.AddBoth(v, w)
+				changes = append(changes, EdgeChange{V: v, W: w, Removed: false})
+			}
+		}
+	}
+
+	return changes
+}
+
+// countTopology - counts rounds in which links are present in graph and their failures
+func (this *gilbertElliott) countTopology() {
+	for i, e := range this.edges {
+		up := this.g.GraphStructure.Edge(e[0], e[1])
+		if up {
+			this.state.UpRounds[i]++
+		} else if !this.state.Down[i] {
+			this.state.Failures[i]++
+		}
+		this.state.Down[i] = !up
+	}
+	this.state.Rounds++
+}
+
+// transition - returns transition probabilities of i-th link in current round
+func (this *gilbertElliott) transition(i int) simulationGraph.LinkTransition {
+	if this.transitions[i] != nil {
+		return *this.transitions[i]
+	}
+	p := this.reliability.probability(this.edges[i][0], this.edges[i][1])
+	return simulationGraph.LinkTransition{GoodToBad: p, BadToGood: 1 - p}
+}
+
+func (this *gilbertElliott) saveState() interface{} {
+	return this.state
+}
+
+func (this *gilbertElliott) restoreState(state interface{}) {
+	this.state = state.(*gilbertElliottState)
+}
+
+// CalculateReliabilityStats - returns uptime of every link (fraction of rounds in which link was present in graph)
+// compared with stationary uptime of the model p_bg/(p_gb+p_bg) (-1 for time-varying reliability expression), number of failures and mean length of failure bursts
+func (this *gilbertElliott) CalculateReliabilityStats() map[string]interface{} {
+	links := make([]linkUptime, 0, len(this.edges))
+	uptimes := make([]float64, 0, len(this.edges))
+	allFailures, allDownRounds := 0, 0
+	for i, e := range this.edges {
+		link := linkUptime{V: e[0], W: e[1], ExpectedUptime: -1, NofFailures: this.state.Failures[i]}
+		if this.state.Rounds > 0 {
+			link.Uptime = float64(this.state.UpRounds[i]) / float64(this.state.Rounds)
+		}
+		if t := this.transitions[i]; t != nil {
+			link.ExpectedUptime = 1
+			if t.GoodToBad+t.BadToGood > 0 {
+				link.ExpectedUptime = t.BadToGood / (t.GoodToBad + t.BadToGood)
+			}
+		} else if _, static := this.reliability.(staticReliability); static {
+			link.ExpectedUptime = 1 - this.reliability.probability(e[0], e[1])
+		}
+		downRounds := this.state.Rounds - this.state.UpRounds[i]
+		if link.NofFailures > 0 {
+			link.MeanBurstLength = float64(downRounds) / float64(link.NofFailures)
+		}
+
+		links = append(links, link)
+		uptimes = append(uptimes, link.Uptime)
+		allFailures += link.NofFailures
+		allDownRounds += downRounds
+	}
+
+	meanUptime, _ := stats.Mean(uptimes)
+	minUptime, _ := stats.Min(uptimes)
+	maxUptime, _ := stats.Max(uptimes)
+	meanBurstLength := 0.
+	if allFailures > 0 {
+		meanBurstLength = float64(allDownRounds) / float64(allFailures)
+	}
+
+	stats := map[string]interface{}{
+		"model":             "gilbert-elliott",
+		"nof_rounds":        this.state.Rounds,
+		"mean_uptime":       meanUptime,
+		"min_uptime":        minUptime,
+		"max_uptime":        maxUptime,
+		"nof_failures":      allFailures,
+		"mean_burst_length": meanBurstLength,
+		"links":             links,
+	}
+	for name, value := range expressionStats(this.reliability) {
+		stats[name] = value
+	}
+
+	return stats
+}
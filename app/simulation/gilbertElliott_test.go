@@ -0,0 +1,40 @@
+package simulation
+
+import (
+	"app/simulationGraph"
+	"testing"
+)
+
+func TestGilbertElliottUptimeCountsScenarioChanges(t *testing.T) {
+	// links never fail in the model, station 4 is isolated by scenario before the first round
+	g := simulationGraph.BuildGrid(3, 3, "gilbert-elliott", "0")
+	conf := RunConfig{
+		Protocol:         "rumor",
+		Seed:             1,
+		Engine:           SequentialEngine,
+		NofWorkers:       1,
+		ReliabilityModel: "gilbert-elliott",
+		Diameter:         4,
+		Graph:            simulationGraph.NewJsonGraphStructure(g),
+		Scenario:         &Scenario{Events: []ScenarioEvent{{Round: 1, Action: ScenarioIsolate, Station: 4}}},
+	}
+	p, manager, err := conf.build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := manager.RunSimulation(p)
+
+	links, ok := result.ReliabilityStats["links"].([]linkUptime)
+	if !ok || len(links) != 12 {
+		t.Fatalf("expected uptime of 12 links, got %v", result.ReliabilityStats["links"])
+	}
+	for _, link := range links {
+		uptime, failures := 1., 0
+		if link.V == 4 || link.W == 4 {
+			uptime, failures = 0, 1
+		}
+		if link.Uptime != uptime || link.NofFailures != failures || link.ExpectedUptime != 1 {
+			t.Errorf("link %d-%d: expected uptime %v with %d failures, got %+v", link.V, link.W, uptime, failures, link)
+		}
+	}
+}
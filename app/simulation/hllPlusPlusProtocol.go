@@ -0,0 +1,224 @@
+package simulation
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+const (
+	// hllSparsePrecision - precision of indices kept in sparse representation
+	hllSparsePrecision = 25
+	hllDenseFormat     = 0.
+	hllSparseFormat    = 1.
+)
+
+// HllPlusPlusProtocol - count distinct protocol based on HyperLogLog++ (bias correction, sparse representation)
+type HllPlusPlusProtocol struct {
+	HllProtocol
+	p uint // precision, m = 2^p
+}
+
+func init() {
+	RegisterProtocol(ProtocolInfo{
+		Name:        "hllPlusPlus",
+		Description: "count distinct elements using HyperLogLog++ sketches propagated by flooding",
+		Parameters:  hllParameters(),
+		Factory: func(params ProtocolParams) (Protocol, error) {
+			hll, err := newHllProtocol(params)
+			if err != nil {
+				return nil, err
+			}
+			if hll.hash.Bits < 64 {
+				return nil, fmt.Errorf("HyperLogLog++ requires 64-bit hash function, %s has %d bits", hll.hash.Name, hll.hash.Bits)
+			}
+
+			return WithState[hllState](HllPlusPlusProtocol{HllProtocol: hll, p: uint(bits.TrailingZeros(hll.m))}), nil
+		},
+	})
+}
+
+// hllPlusPlusSketch - HyperLogLog++ sketch in sparse or dense representation
+type hllPlusPlusSketch struct {
+	sparse    map[uint64]float64 // sparse index -> rho, nil in dense representation
+	registers []float64
+	p         uint
+}
+
+func (p HllPlusPlusProtocol) GetInitialData(station IStation, state *hllState) {
+	s := hllPlusPlusSketch{sparse: map[uint64]float64{}, p: p.p}
+	for _, b := range p.observeValues(station) {
+		s.add(p.hash.Sum(b))
+	}
+	s.convertIfNecessary()
+
+	station.SetCurrentData(s.encode())
+}
+
+func (p HllPlusPlusProtocol) OnDataReceive(station IStation, state *hllState) {
+	state.vectorChanged = false
+	s := decodeHllPlusPlusSketch(station.GetCurrentData(), p.p)
+	mq := station.GetMsgQueue()
+	for mq.Len() > 0 {
+		msg := mq.Dequeue()
+		if s.merge(decodeHllPlusPlusSketch(msg.Data, p.p)) {
+			state.vectorChanged = true
+		}
+	}
+
+	if state.vectorChanged {
+		s.convertIfNecessary()
+		station.SetCurrentData(s.encode())
+	}
+}
+
+func (p HllPlusPlusProtocol) OnFinalize(station IStation, state *hllState) {
+	s := decodeHllPlusPlusSketch(station.GetCurrentData(), p.p)
+	station.SetResult(math.Round(s.estimate()))
+}
+
+// add - adds 64-bit hash to sketch
+func (s *hllPlusPlusSketch) add(x uint64) {
+	if s.sparse == nil {
+		j := x >> (64 - s.p)
+		r := float64(leftmostSignificantBitPosition(x << s.p))
+		if maxR := float64(64 - s.p + 1); r > maxR {
+			r = maxR
+		}
+		if r > s.registers[j] {
+			s.registers[j] = r
+		}
+		return
+	}
+
+	idx := x >> (64 - hllSparsePrecision)
+	r := float64(leftmostSignificantBitPosition(x << hllSparsePrecision))
+	if maxR := float64(64 - hllSparsePrecision + 1); r > maxR {
+		r = maxR
+	}
+	if r > s.sparse[idx] {
+		s.sparse[idx] = r
+	}
+}
+
+// merge - merges other sketch into s, returns true if s changed
+func (s *hllPlusPlusSketch) merge(other hllPlusPlusSketch) bool {
+	changed := false
+	if s.sparse != nil && other.sparse != nil {
+		for idx, r := range other.sparse {
+			if r > s.sparse[idx] {
+				s.sparse[idx] = r
+				changed = true
+			}
+		}
+		return changed
+	}
+
+	if s.sparse != nil {
+		s.toDense()
+	}
+	if other.sparse != nil {
+		other.toDense()
+	}
+	for i, r := range other.registers {
+		if r > s.registers[i] {
+			s.registers[i] = r
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+// convertIfNecessary - switches to dense representation when sparse one stops being smaller,
+// sparse entry needs 32 bits and dense register 6 bits
+func (s *hllPlusPlusSketch) convertIfNecessary() {
+	m := 1 << s.p
+	if s.sparse != nil && len(s.sparse)*32 > 6*m {
+		s.toDense()
+	}
+}
+
+// toDense - converts sparse representation to dense registers of precision p
+func (s *hllPlusPlusSketch) toDense() {
+	s.registers = make([]float64, 1<<s.p)
+	shift := hllSparsePrecision - s.p
+	for idx, sparseR := range s.sparse {
+		j := idx >> shift
+		rest := idx & (1<<shift - 1)
+		var r float64
+		if rest != 0 {
+			r = float64(shift - uint(bits.Len64(rest)) + 1)
+		} else {
+			r = float64(shift) + sparseR
+		}
+		if r > s.registers[j] {
+			s.registers[j] = r
+		}
+	}
+	s.sparse = nil
+}
+
+// estimate - HyperLogLog++ cardinality estimate
+func (s *hllPlusPlusSketch) estimate() float64 {
+	if s.sparse != nil {
+		mPrime := float64(uint64(1) << hllSparsePrecision)
+		return linearCounting(mPrime, int(mPrime)-len(s.sparse))
+	}
+
+	m := float64(len(s.registers))
+	estimate, numOfRegistersEqualToZero := rawEstimate(s.registers)
+	if estimate <= 5*m {
+		estimate -= getHllBiasData(s.p).estimateBias(estimate)
+	}
+
+	if numOfRegistersEqualToZero != 0 {
+		h := linearCounting(m, numOfRegistersEqualToZero)
+		if h <= hllThresholds[s.p-4] {
+			return h
+		}
+	}
+
+	return estimate
+}
+
+// encode - encodes sketch as message data, first element is representation format,
+// sparse entries are encoded as index << 6 | rho
+func (s *hllPlusPlusSketch) encode() []float64 {
+	if s.sparse == nil {
+		data := make([]float64, 0, len(s.registers)+1)
+		data = append(data, hllDenseFormat)
+		return append(data, s.registers...)
+	}
+
+	entries := make([]uint64, 0, len(s.sparse))
+	for idx, r := range s.sparse {
+		entries = append(entries, idx<<6|uint64(r))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i] < entries[j] })
+
+	data := make([]float64, 0, len(entries)+1)
+	data = append(data, hllSparseFormat)
+	for _, e := range entries {
+		data = append(data, float64(e))
+	}
+
+	return data
+}
+
+func decodeHllPlusPlusSketch(data []float64, p uint) hllPlusPlusSketch {
+	if data[0] == hllDenseFormat {
+		registers := make([]float64, len(data)-1)
+		copy(registers, data[1:])
+		return hllPlusPlusSketch{registers: registers, p: p}
+	}
+
+	sparse := make(map[uint64]float64, len(data)-1)
+	for _, e := range data[1:] {
+		entry := uint64(e)
+		sparse[entry>>6] = float64(entry & 63)
+	}
+
+	return hllPlusPlusSketch{sparse: sparse, p: p}
+}
@@ -0,0 +1,48 @@
+package simulation
+
+import (
+	"app/hashing"
+	"math"
+	"testing"
+)
+
+func TestHllPlusPlusEstimateError(t *testing.T) {
+	tests := []struct {
+		p           uint
+		cardinality int
+	}{
+		{p: 4, cardinality: 30},
+		{p: 10, cardinality: 100},
+		{p: 10, cardinality: 2000},
+		{p: 10, cardinality: 4000},
+		{p: 10, cardinality: 50000},
+		{p: 14, cardinality: 10000},
+		{p: 14, cardinality: 60000},
+		{p: 14, cardinality: 200000},
+	}
+
+	for _, test := range tests {
+		s := hllPlusPlusSketch{sparse: map[uint64]float64{}, p: test.p}
+		for i := 0; i < test.cardinality; i++ {
+			s.add(hashing.XXHash64(valueToBytes(float64(i))))
+			s.convertIfNecessary()
+		}
+
+		// 4 standard errors of HyperLogLog estimate
+		bound := 4 * 1.04 / math.Sqrt(float64(uint64(1)<<test.p))
+		relativeError := math.Abs(s.estimate()-float64(test.cardinality)) / float64(test.cardinality)
+		if relativeError > bound {
+			t.Errorf("p=%d, cardinality %d: estimate %.0f, relative error %.4f exceeds %.4f",
+				test.p, test.cardinality, s.estimate(), relativeError, bound)
+		}
+	}
+}
+
+func TestHllBiasTables(t *testing.T) {
+	for p := uint(4); p <= 18; p++ {
+		data := getHllBiasData(p)
+		if len(data.rawEstimates) == 0 || len(data.rawEstimates) != len(data.biases) {
+			t.Fatalf("p=%d: %d raw estimates, %d biases", p, len(data.rawEstimates), len(data.biases))
+		}
+	}
+}
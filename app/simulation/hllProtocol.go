@@ -1,57 +1,98 @@
 package simulation
 
 import (
-	"encoding/binary"
+	"app/hashing"
 	"fmt"
-	"hash/fnv"
 	"math"
 	"math/bits"
-	"math/rand"
 )
 
 // HllProtocol - count distinct protocol
-type HllProtocol struct{}
+type HllProtocol struct {
+	valueObserver
+	m    uint // number of registers
+	hash hashing.HashFunction
+}
+
+func init() {
+	RegisterProtocol(ProtocolInfo{
+		Name:        "hll",
+		Description: "count distinct elements using HyperLogLog registers propagated by flooding",
+		Parameters:  hllParameters(),
+		Factory: func(params ProtocolParams) (Protocol, error) {
+			p, err := newHllProtocol(params)
+			if err != nil {
+				return nil, err
+			}
+
+			return WithState[hllState](p), nil
+		},
+	})
+}
+
+// hllParameters - parameters shared by HyperLogLog based protocols
+func hllParameters() []ProtocolParameter {
+	return append([]ProtocolParameter{
+		{Name: "m", Type: IntParameter, Default: "32", Description: "number of registers (power of 2, 16..65536)"},
+		{Name: "hash", Type: StringParameter, Default: "fnv64", Description: "hash function (fnv32|fnv64|xxhash|murmur3)"},
+	}, observationParameters()...)
+}
+
+func newHllProtocol(params ProtocolParams) (HllProtocol, error) {
+	m := params.Int("m")
+	if m < 16 || m > 1<<16 || m&(m-1) != 0 {
+		return HllProtocol{}, fmt.Errorf("number of registers should be a power of 2 in range [16, 65536], got %d", m)
+	}
+
+	observer, err := newValueObserver(params)
+	if err != nil {
+		return HllProtocol{}, err
+	}
+
+	hash, err := hashing.Get(params.String("hash"))
+	if err != nil {
+		return HllProtocol{}, err
+	}
+
+	return HllProtocol{valueObserver: observer, m: uint(m), hash: hash}, nil
+}
+
+// hllState - state of station in HyperLogLog based protocols
+type hllState struct {
+	vectorChanged bool
+}
 
 type HyperLogLog struct {
 	registers []float64
 	m         uint // number of registers
 	b         uint
+	hash      hashing.HashFunction
 }
 
-func NewHyperLogLog(m uint) HyperLogLog {
+func NewHyperLogLog(m uint, hash hashing.HashFunction) HyperLogLog {
 	return HyperLogLog{
 		registers: make([]float64, m),
 		m:         m,
 		b:         uint(math.Ceil(math.Log2(float64(m)))),
+		hash:      hash,
 	}
 }
 
-func (HllProtocol) GetInitialData(station IStation) {
-	min := 1
-	max := 1000000
-	observedValuesAsBytes := make([][]byte, 0)
-	for i := 0; i < 20; i++ {
-		randomValue := min + rand.Intn(max+1)
-		b := make([]byte, 4)
-		binary.LittleEndian.PutUint32(b, uint32(randomValue))
-		station.ObserveValue([]float64{float64(randomValue)})
-		observedValuesAsBytes = append(observedValuesAsBytes, b)
-	}
-
-	h := NewHyperLogLog(32)
-	for _, b := range observedValuesAsBytes {
+func (p HllProtocol) GetInitialData(station IStation, state *hllState) {
+	h := NewHyperLogLog(p.m, p.hash)
+	for _, b := range p.observeValues(station) {
 		h.Add(b)
 	}
 
 	station.SetCurrentData(h.registers)
 }
 
-func (HllProtocol) OnInitialize(station IStation) {
+func (HllProtocol) OnInitialize(station IStation, state *hllState) {
 	station.Broadcast()
 }
 
-func (HllProtocol) OnDataReceive(station IStation) {
-	station.SetUserDefinedVariable("vectorChanged", false)
+func (HllProtocol) OnDataReceive(station IStation, state *hllState) {
+	state.vectorChanged = false
 	mq := station.GetMsgQueue()
 	currentVector := station.GetCurrentData()
 	for mq.Len() > 0 {
@@ -59,76 +100,64 @@ func (HllProtocol) OnDataReceive(station IStation) {
 		vector := msg.Data
 		for i, element := range vector {
 			if currentVector[i] < element {
-				station.SetUserDefinedVariable("vectorChanged", true)
+				state.vectorChanged = true
 				currentVector[i] = element
 			}
 		}
 	}
 }
 
-func (HllProtocol) OnDataPropagate(station IStation) {
-	vectorChanged := station.GetUserDefinedVariable("vectorChanged").(bool)
-	if vectorChanged {
+func (HllProtocol) OnDataPropagate(station IStation, state *hllState) {
+	if state.vectorChanged {
 		station.SynchronizedBroadcast()
 	}
 }
 
-func (HllProtocol) StopCondition(station IStation) bool {
-	return station.GetRoundCounter() < station.GetGraph().GetDiameter()
+func (HllProtocol) StateChanged(station IStation, state *hllState) bool {
+	return state.vectorChanged
 }
 
-func (HllProtocol) OnFinalize(station IStation) {
-	currentVector := station.GetCurrentData()
-	sum := 0.
-	m := 32.0
+func (HllProtocol) StopCondition(station IStation, state *hllState) bool {
+	return station.GetRoundCounter() < station.GetGraph().GetDiameter()
+}
 
-	numOfRegistersEqualToZero := 0
-	for _, v := range currentVector {
-		if v != 0 {
-			sum += math.Pow(math.Pow(2, float64(v)), -1)
-		} else {
-			numOfRegistersEqualToZero++
-		}
-	}
-	estimate := .697 * m * m / sum
-	station.SetResult(float64(rangeCorrection(estimate, numOfRegistersEqualToZero)))
+func (p HllProtocol) OnFinalize(station IStation, state *hllState) {
+	estimate, numOfRegistersEqualToZero := rawEstimate(station.GetCurrentData())
+	station.SetResult(float64(rangeCorrection(estimate, int(p.m), numOfRegistersEqualToZero, p.hash.Bits)))
 }
 
-func (HllProtocol) CalculateStationExactResult(station IStation) float64 {
+func (HllProtocol) CalculateStationExactResult(station IStation, state *hllState) float64 {
 	return -1
 }
 
 func (HllProtocol) CalculateGlobalExactResult(stations *[]IStation) float64 {
-	m := map[float64]struct{}{}
-
-	for _, station := range *stations {
-		for _, i := range station.GetObservedValues() {
-			if _, ok := m[i[0]]; !ok {
-				m[i[0]] = struct{}{}
-			}
-		}
-	}
-
-	return float64(len(m))
+	return float64(len(exactUnion(stations)))
 }
 
-func leftmostSignificantBitPosition(x uint32) int {
-	return 1 + bits.LeadingZeros32(x)
+// alpha - bias correction constant for m registers
+func alpha(m uint) float64 {
+	switch m {
+	case 16:
+		return 0.673
+	case 32:
+		return 0.697
+	case 64:
+		return 0.709
+	}
+	return 0.7213 / (1 + 1.079/float64(m))
 }
 
-func create32BitHash(stream []byte) uint32 {
-	h := fnv.New32()
-	h.Write(stream)
-	sum := h.Sum32()
-	h.Reset()
-	return sum
+func leftmostSignificantBitPosition(x uint64) int {
+	return 1 + bits.LeadingZeros64(x)
 }
 
 func (h HyperLogLog) Add(data []byte) HyperLogLog {
-	x := create32BitHash(data)
-	k := 32 - h.b // first b bits
+	x := h.hash.Sum(data) << (64 - h.hash.Bits) // align hash to the left
+	j := x >> (64 - h.b)                        // first b bits
 	r := float64(leftmostSignificantBitPosition(x << h.b))
-	j := x >> uint(k)
+	if maxR := float64(h.hash.Bits - h.b + 1); r > maxR {
+		r = maxR
+	}
 
 	if r > h.registers[j] {
 		h.registers[j] = r
@@ -137,22 +166,40 @@ func (h HyperLogLog) Add(data []byte) HyperLogLog {
 	return h
 }
 
-func rangeCorrection(estimate float64, numOfRegistersEqualToZero int) uint64 {
+// rawEstimate - returns raw HyperLogLog estimate and number of registers equal to zero
+func rawEstimate(registers []float64) (float64, int) {
+	m := float64(len(registers))
+	sum := 0.
+	numOfRegistersEqualToZero := 0
+	for _, v := range registers {
+		sum += math.Ldexp(1, -int(v))
+		if v == 0 {
+			numOfRegistersEqualToZero++
+		}
+	}
+
+	return alpha(uint(len(registers))) * m * m / sum, numOfRegistersEqualToZero
+}
+
+// linearCounting - estimates cardinality based on number of empty registers
+func linearCounting(m float64, numOfRegistersEqualToZero int) float64 {
+	return m * math.Log(m/float64(numOfRegistersEqualToZero))
+}
+
+func rangeCorrection(estimate float64, m int, numOfRegistersEqualToZero int, hashBits uint) uint64 {
 	var result uint64
-	m := 32
-	if estimate <= (5/2)*float64(m) {
+	if estimate <= 2.5*float64(m) {
 		if numOfRegistersEqualToZero != 0 {
-			result = uint64(float64(m) * math.Log2(float64(m/numOfRegistersEqualToZero)))
-			fmt.Println("lol")
+			result = uint64(linearCounting(float64(m), numOfRegistersEqualToZero))
 		} else {
 			result = uint64(estimate)
 		}
-	} else if estimate <= math.Pow(2, 32)/30 {
+	} else if hashBits > 32 || estimate <= math.Pow(2, 32)/30 {
+		// large range correction is needed only for 32-bit hashes
 		result = uint64(estimate)
 	} else {
 		x := math.Pow(2, 32)
-		result = uint64(-1 * x * math.Log2(1-estimate/x))
-		fmt.Println("lol2")
+		result = uint64(-1 * x * math.Log(1-estimate/x))
 	}
 	return result
 }
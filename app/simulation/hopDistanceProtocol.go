@@ -0,0 +1,147 @@
+package simulation
+
+import (
+	"app/simulationGraph"
+	"fmt"
+	"math"
+)
+
+// HopDistanceProtocol - computes hop distances from root by flooding improved distances, stations stop
+// when Dijkstra-Scholten termination detection started by root reports that the computation terminated
+type HopDistanceProtocol struct {
+	root        int
+	termination dijkstraScholten
+	// exactDistances - distances from root computed by BFS on original graph
+	exactDistances *exactValues
+}
+
+// hopDistanceState - state of station computing hop distance
+type hopDistanceState struct {
+	improved    bool // distance improved in current round and is sent to neighbours
+	termination dsState
+}
+
+func init() {
+	RegisterProtocol(ProtocolInfo{
+		Name:        "hopDistance",
+		Description: "compute hop distances from root with Dijkstra-Scholten termination detection",
+		Parameters: []ProtocolParameter{
+			{Name: "root", Type: IntParameter, Default: "0", Description: "id of root station"},
+		},
+		Factory: func(params ProtocolParams) (Protocol, error) {
+			root := params.Int("root")
+			if root < 0 {
+				return nil, fmt.Errorf("root should be non-negative, got %d", root)
+			}
+
+			p := HopDistanceProtocol{root: root, termination: dijkstraScholten{root: root}}
+			p.exactDistances = newExactValues(p.distances)
+			return WithState[hopDistanceState](p), nil
+		},
+	})
+}
+
+func (p HopDistanceProtocol) GetInitialData(station IStation, state *hopDistanceState) {
+	distance := math.MaxFloat64
+	if station.GetId() == p.root {
+		distance = 0
+	}
+	station.SetCurrentData([]float64{distance})
+	state.improved = station.GetId() == p.root
+	p.termination.initialize(station, &state.termination)
+}
+
+func (p HopDistanceProtocol) OnInitialize(station IStation, state *hopDistanceState) {
+	p.OnDataPropagate(station, state)
+}
+
+func (p HopDistanceProtocol) OnDataReceive(station IStation, state *hopDistanceState) {
+	distance := station.GetCurrentData()[0]
+	state.improved = false
+	for _, msg := range p.termination.receive(station, &state.termination) {
+		if d := msg.payload[0] + 1; d < distance {
+			distance = d
+			state.improved = true
+		}
+	}
+
+	if state.improved {
+		station.SetCurrentData([]float64{distance})
+	}
+}
+
+func (p HopDistanceProtocol) OnDataPropagate(station IStation, state *hopDistanceState) {
+	if state.improved {
+		for _, w := range station.GetNeighbours() {
+			p.termination.send(&state.termination, w, station.GetCurrentData())
+		}
+	}
+	p.termination.flush(station, &state.termination)
+}
+
+func (p HopDistanceProtocol) StopCondition(station IStation, state *hopDistanceState) bool {
+	// without root there is no computation whose termination could be detected
+	return p.root < station.GetGraph().GraphStructure.Order() && !p.termination.finished(&state.termination)
+}
+
+func (p HopDistanceProtocol) OnFinalize(station IStation, state *hopDistanceState) {
+	station.SetResult(station.GetCurrentData()[0])
+}
+
+// distances - returns exact hop distances from root (BFS on original graph)
+func (p HopDistanceProtocol) distances(g *simulationGraph.GraphWrapper) []float64 {
+	neighbours := g.GetOriginalNeighbours()
+	distances := make([]float64, len(neighbours))
+	for v := range distances {
+		distances[v] = math.MaxFloat64
+	}
+	if p.root < len(distances) {
+		distances[p.root] = 0
+		for queue := []int{p.root}; len(queue) > 0; queue = queue[1:] {
+			for _, w := range neighbours[queue[0]] {
+				if distances[w] == math.MaxFloat64 {
+					distances[w] = distances[queue[0]] + 1
+					queue = append(queue, w)
+				}
+			}
+		}
+	}
+
+	return distances
+}
+
+func (p HopDistanceProtocol) CalculateStationExactResult(station IStation, state *hopDistanceState) float64 {
+	return p.exactDistances.of(station.GetGraph())[station.GetId()]
+}
+
+// CalculateGlobalExactResult - returns eccentricity of root
+func (p HopDistanceProtocol) CalculateGlobalExactResult(stations *[]IStation) float64 {
+	eccentricity := 0.
+	for _, d := range p.exactDistances.of((*stations)[0].GetGraph()) {
+		if d != math.MaxFloat64 {
+			eccentricity = math.Max(eccentricity, d)
+		}
+	}
+
+	return eccentricity
+}
+
+func (p HopDistanceProtocol) CalculateProtocolStats(stations *[]IStation) map[string]interface{} {
+	wrongDistance, controlMsgs, detectionRound := 0, 0, -1
+	for _, station := range *stations {
+		if station.GetStation().Result != station.GetStation().ExactResult {
+			wrongDistance++
+		}
+		termination := &StateOf[hopDistanceState](station).termination
+		controlMsgs += termination.nofControlMsgs
+		if station.GetId() == p.root {
+			detectionRound = termination.detectionRound
+		}
+	}
+
+	return map[string]interface{}{
+		"stations_with_wrong_distance": wrongDistance,
+		"detection_round":              detectionRound,
+		"control_msgs":                 controlMsgs,
+	}
+}
@@ -0,0 +1,269 @@
+package simulation
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// KMeansProtocol - distributed k-means clustering of observed points. Every Lloyd iteration lasts
+// gossipRounds rounds: stations assign their points to current centroids and compute per-cluster
+// mass (sum of points, count, sum of squared norms), the mass is averaged by push-sum-like gossip
+// (station keeps 1/(deg+1) of its mass and sends the same share to every neighbour), at the end
+// of the iteration new centroids are ratios of gossiped sums and counts.
+// Points are drawn from k gaussian blobs, blob centres and initial centroids are derived from seed,
+// so all stations (and the centralised reference) start from the same centroids.
+type KMeansProtocol struct {
+	k              int
+	dim            int
+	nofObservables int
+	spread         float64
+	stddev         float64
+	iterations     int
+	gossipRounds   int
+
+	blobCentres      [][]float64
+	initialCentroids [][]float64
+}
+
+// kMeansState - state of station in distributed k-means
+type kMeansState struct {
+	centroids [][]float64
+	// mse - mean squared distance of points to centroids estimated in the last iteration
+	mse float64
+}
+
+func init() {
+	RegisterProtocol(ProtocolInfo{
+		Name:        "kMeans",
+		Description: "cluster observed points with distributed k-means (gossip averaging of cluster sums and counts)",
+		Parameters: []ProtocolParameter{
+			{Name: "k", Type: IntParameter, Default: "3", Description: "number of clusters"},
+			{Name: "dim", Type: IntParameter, Default: "2", Description: "dimension of observed points"},
+			{Name: "observations", Type: IntParameter, Default: "10", Description: "number of points observed by each station"},
+			{Name: "range", Type: FloatParameter, Default: "100", Description: "blob centres and initial centroids are drawn from [0,range]^dim"},
+			{Name: "stddev", Type: FloatParameter, Default: "5", Description: "standard deviation of points around blob centre"},
+			{Name: "iterations", Type: IntParameter, Default: "10", Description: "number of Lloyd iterations"},
+			{Name: "gossip-rounds", Type: IntParameter, Default: "20", Description: "number of gossip rounds in every iteration"},
+			{Name: "seed", Type: IntParameter, Default: "1", Description: "seed of blob centres and initial centroids"},
+		},
+		Factory: func(params ProtocolParams) (Protocol, error) {
+			p := KMeansProtocol{
+				k:              params.Int("k"),
+				dim:            params.Int("dim"),
+				nofObservables: params.Int("observations"),
+				spread:         params.Float("range"),
+				stddev:         params.Float("stddev"),
+				iterations:     params.Int("iterations"),
+				gossipRounds:   params.Int("gossip-rounds"),
+			}
+			if p.k <= 0 || p.dim <= 0 || p.nofObservables <= 0 || p.iterations <= 0 || p.gossipRounds <= 0 {
+				return nil, fmt.Errorf("k, dim, observations, iterations and gossip-rounds should be positive")
+			}
+			if p.spread <= 0 || p.stddev < 0 {
+				return nil, fmt.Errorf("range should be positive and stddev non-negative")
+			}
+
+			rng := rand.New(rand.NewSource(int64(params.Int("seed"))))
+			p.blobCentres = randomPoints(rng, p.k, p.dim, p.spread)
+			p.initialCentroids = randomPoints(rng, p.k, p.dim, p.spread)
+			return WithState[kMeansState](p), nil
+		},
+	})
+}
+
+func randomPoints(rng *rand.Rand, count, dim int, spread float64) [][]float64 {
+	points := make([][]float64, count)
+	for i := range points {
+		points[i] = make([]float64, dim)
+		for j := range points[i] {
+			points[i][j] = rng.Float64() * spread
+		}
+	}
+
+	return points
+}
+
+// massSize - length of cluster mass vector: for every cluster sum of points, count and sum of squared norms
+func (p KMeansProtocol) massSize() int {
+	return p.k * (p.dim + 2)
+}
+
+// nearestCentroid - returns index of centroid closest to point and squared distance to it
+func nearestCentroid(point []float64, centroids [][]float64) (int, float64) {
+	best, bestDistance := 0, math.Inf(1)
+	for c, centroid := range centroids {
+		distance := 0.
+		for j := range point {
+			distance += (point[j] - centroid[j]) * (point[j] - centroid[j])
+		}
+		if distance < bestDistance {
+			best, bestDistance = c, distance
+		}
+	}
+
+	return best, bestDistance
+}
+
+// localMass - computes cluster mass of given points assigned to centroids
+func (p KMeansProtocol) localMass(points [][]float64, centroids [][]float64) []float64 {
+	mass := make([]float64, p.massSize())
+	for _, point := range points {
+		c, _ := nearestCentroid(point, centroids)
+		offset := c * (p.dim + 2)
+		for j, v := range point {
+			mass[offset+j] += v
+			mass[offset+p.dim+1] += v * v
+		}
+		mass[offset+p.dim]++
+	}
+
+	return mass
+}
+
+// centroidsFromMass - returns new centroids (cluster means) and mean squared distance of points
+// to their centroids, empty clusters keep previous centroid
+func (p KMeansProtocol) centroidsFromMass(mass []float64, previous [][]float64) ([][]float64, float64) {
+	centroids := make([][]float64, p.k)
+	totalCount, sse := 0., 0.
+	for c := range centroids {
+		offset := c * (p.dim + 2)
+		count := mass[offset+p.dim]
+		totalCount += count
+		centroids[c] = make([]float64, p.dim)
+		if count <= 0 {
+			copy(centroids[c], previous[c])
+			continue
+		}
+
+		sse += mass[offset+p.dim+1]
+		for j := range centroids[c] {
+			centroids[c][j] = mass[offset+j] / count
+			sse -= count * centroids[c][j] * centroids[c][j]
+		}
+	}
+	if totalCount <= 0 {
+		return centroids, 0
+	}
+
+	return centroids, math.Max(0, sse/totalCount)
+}
+
+func (p KMeansProtocol) GetInitialData(station IStation, state *kMeansState) {
+	for i := 0; i < p.nofObservables; i++ {
+		blob := p.blobCentres[station.GetRandom().Intn(p.k)]
+		point := make([]float64, p.dim)
+		for j := range point {
+			point[j] = blob[j] + p.stddev*station.GetRandom().NormFloat64()
+		}
+		station.ObserveValue(point)
+	}
+
+	state.centroids = p.initialCentroids
+	station.SetCurrentData(p.localMass(station.GetObservedValues(), p.initialCentroids))
+}
+
+func (p KMeansProtocol) OnInitialize(station IStation, state *kMeansState) {
+	p.OnDataPropagate(station, state)
+}
+
+func (p KMeansProtocol) OnDataReceive(station IStation, state *kMeansState) {
+	mass := append([]float64{}, station.GetCurrentData()...)
+	mq := station.GetMsgQueue()
+	for mq.Len() > 0 {
+		msg := mq.Dequeue()
+		for i, v := range msg.Data {
+			mass[i] += v
+		}
+	}
+	station.SetCurrentData(mass)
+
+	if (station.GetRoundCounter()+1)%p.gossipRounds != 0 {
+		return
+	}
+	// end of iteration, all shares sent in this iteration were already received
+	state.centroids, state.mse = p.centroidsFromMass(mass, state.centroids)
+	station.SetCurrentData(p.localMass(station.GetObservedValues(), state.centroids))
+}
+
+func (p KMeansProtocol) OnDataPropagate(station IStation, state *kMeansState) {
+	if station.GetRoundCounter()+1 >= p.iterations*p.gossipRounds {
+		return
+	}
+
+	mass := append([]float64{}, station.GetCurrentData()...)
+	neighbours := station.GetNeighbours()
+	share := make([]float64, len(mass))
+	for i, v := range mass {
+		share[i] = v / float64(len(neighbours)+1)
+	}
+	for _, w := range neighbours {
+		if station.Send(w, share) {
+			// mass sent over broken links stays at the station
+			for i := range mass {
+				mass[i] -= share[i]
+			}
+		}
+	}
+	station.SetCurrentData(mass)
+}
+
+func (p KMeansProtocol) StopCondition(station IStation, state *kMeansState) bool {
+	return station.GetRoundCounter() < p.iterations*p.gossipRounds
+}
+
+// OnFinalize - result is station's estimate of mean squared distance of points to their centroids
+func (KMeansProtocol) OnFinalize(station IStation, state *kMeansState) {
+	station.SetResult(state.mse)
+}
+
+func (KMeansProtocol) CalculateStationExactResult(station IStation, state *kMeansState) float64 {
+	return -1
+}
+
+// lloyd - centralised k-means with the same initial centroids and number of iterations,
+// returns final centroids and mean squared distance of points to their centroids
+func (p KMeansProtocol) lloyd(stations *[]IStation) ([][]float64, float64) {
+	points := make([][]float64, 0)
+	for _, station := range *stations {
+		points = append(points, station.GetObservedValues()...)
+	}
+
+	centroids, mse := p.initialCentroids, 0.
+	for i := 0; i < p.iterations; i++ {
+		centroids, mse = p.centroidsFromMass(p.localMass(points, centroids), centroids)
+	}
+
+	return centroids, mse
+}
+
+// CalculateGlobalExactResult - returns mean squared distance of points to centroids found by Lloyd algorithm
+func (p KMeansProtocol) CalculateGlobalExactResult(stations *[]IStation) float64 {
+	_, mse := p.lloyd(stations)
+	return mse
+}
+
+func (p KMeansProtocol) CalculateProtocolStats(stations *[]IStation) map[string]interface{} {
+	reference, _ := p.lloyd(stations)
+	maxError, sumError := 0., 0.
+	for _, station := range *stations {
+		centroids := StateOf[kMeansState](station).centroids
+		stationError := 0.
+		for c := range centroids {
+			distance := 0.
+			for j := range centroids[c] {
+				distance += (centroids[c][j] - reference[c][j]) * (centroids[c][j] - reference[c][j])
+			}
+			stationError = math.Max(stationError, math.Sqrt(distance))
+		}
+		maxError = math.Max(maxError, stationError)
+		sumError += stationError
+	}
+
+	return map[string]interface{}{
+		"lloyd_centroids":     reference,
+		"max_centroid_error":  maxError,
+		"mean_centroid_error": sumError / float64(len(*stations)),
+		"total_rounds":        p.iterations * p.gossipRounds,
+	}
+}
@@ -0,0 +1,34 @@
+package simulation
+
+import (
+	"app/simulationGraph"
+	"math"
+	"testing"
+)
+
+func TestKMeansMatchesLloyd(t *testing.T) {
+	tests := []struct {
+		name     string
+		g        *simulationGraph.GraphWrapper
+		diameter int
+		params   string
+	}{
+		{"clique", simulationGraph.BuildClique(6, "", "0"), 1, "gossip-rounds=30"},
+		{"grid", simulationGraph.BuildGrid(3, 3, "", "0"), 4, "gossip-rounds=80"},
+		{"hypercube", simulationGraph.BuildHyperCube(3, "", "0"), 3, "k=4,dim=3,gossip-rounds=60"},
+	}
+
+	for _, test := range tests {
+		result := runOnGraph(t, "kMeans", test.params, test.g, test.diameter)
+		stats := result.ProtocolStats
+		// range of blob centres is 100, so errors are relative to it
+		if maxError := stats["max_centroid_error"].(float64); maxError > 0.01 {
+			t.Errorf("%s: centroids of stations are up to %v away from Lloyd centroids", test.name, maxError)
+		}
+		for _, station := range result.Stations {
+			if math.Abs(station.Result-result.Result) > 1e-3*result.Result {
+				t.Errorf("%s: station %d estimated mean squared distance %v, Lloyd %v", test.name, station.GetId(), station.Result, result.Result)
+			}
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package simulation
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// kllMaxLevels - number of levels kept in sketch, weight of items below them is negligible
+const kllMaxLevels = 64
+
+// kllSketch - mergeable quantile sketch (Karnin, Lang, Liberty), items at level h have weight 2^(offset+h)
+type kllSketch struct {
+	k          int
+	offset     int // number of the lowest levels discarded by trim
+	compactors [][]float64
+	size       int
+	maxSize    int
+}
+
+func newKllSketch(k int) *kllSketch {
+	s := &kllSketch{k: k}
+	s.grow()
+	return s
+}
+
+// grow - adds new level of compactors
+func (s *kllSketch) grow() {
+	s.compactors = append(s.compactors, make([]float64, 0))
+	s.maxSize = 0
+	for h := range s.compactors {
+		s.maxSize += s.capacity(h)
+	}
+}
+
+// capacity - number of items which can be stored at level h
+func (s *kllSketch) capacity(h int) int {
+	depth := len(s.compactors) - h - 1
+	return int(math.Ceil(float64(s.k)*math.Pow(2./3., float64(depth)))) + 1
+}
+
+// Update - adds single value to sketch, rng decides which items survive compaction
+func (s *kllSketch) Update(value float64, rng *rand.Rand) {
+	s.compactors[0] = append(s.compactors[0], value)
+	s.size++
+	if s.size >= s.maxSize {
+		s.compress(rng)
+	}
+}
+
+// compress - compacts the lowest level exceeding its capacity
+func (s *kllSketch) compress(rng *rand.Rand) {
+	for h := 0; h < len(s.compactors); h++ {
+		if len(s.compactors[h]) < s.capacity(h) {
+			continue
+		}
+		if h+1 >= len(s.compactors) {
+			s.grow()
+		}
+
+		items := s.compactors[h]
+		sort.Float64s(items)
+		// with odd number of items the largest one stays on its level
+		var leftover []float64
+		if len(items)%2 == 1 {
+			leftover = []float64{items[len(items)-1]}
+			items = items[:len(items)-1]
+		}
+		for i := rng.Intn(2); i < len(items); i += 2 {
+			s.compactors[h+1] = append(s.compactors[h+1], items[i])
+		}
+		s.compactors[h] = append(make([]float64, 0), leftover...)
+		s.updateSize()
+		return
+	}
+}
+
+func (s *kllSketch) updateSize() {
+	s.size = 0
+	for _, c := range s.compactors {
+		s.size += len(c)
+	}
+}
+
+// Merge - merges other sketch into s, levels are aligned by weight of their items
+func (s *kllSketch) Merge(other *kllSketch, rng *rand.Rand) {
+	if other.offset > s.offset {
+		// levels of other sketch are heavier, so s discards its levels below them
+		s.trimTo(len(s.compactors) - (other.offset - s.offset))
+	}
+	shift := other.offset - s.offset
+	for len(s.compactors) < len(other.compactors)+shift {
+		s.grow()
+	}
+	for h, c := range other.compactors {
+		if h+shift >= 0 {
+			s.compactors[h+shift] = append(s.compactors[h+shift], c...)
+		}
+	}
+	s.updateSize()
+
+	for s.size >= s.maxSize {
+		s.compress(rng)
+	}
+	s.trimTo(kllMaxLevels)
+}
+
+// trimTo - discards the lowest levels so that at most given number of levels remain, with at most
+// kllMaxLevels levels items of discarded levels weigh less than 2^-kllMaxLevels of the top level items
+func (s *kllSketch) trimTo(nofLevels int) {
+	if excess := len(s.compactors) - nofLevels; excess > 0 {
+		s.compactors = append(make([][]float64, 0, nofLevels), s.compactors[excess:]...)
+		s.offset += excess
+		s.updateSize()
+	}
+}
+
+// Quantile - returns estimated q-quantile (nearest rank)
+func (s *kllSketch) Quantile(q float64) float64 {
+	type weightedItem struct {
+		value  float64
+		weight float64
+	}
+	items := make([]weightedItem, 0, s.size)
+	totalWeight := 0.
+	for h, c := range s.compactors {
+		weight := math.Ldexp(1, h)
+		for _, v := range c {
+			items = append(items, weightedItem{v, weight})
+			totalWeight += weight
+		}
+	}
+	if len(items) == 0 {
+		return 0
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].value < items[j].value })
+
+	rank := math.Max(1, math.Ceil(q*totalWeight))
+	cumulativeWeight := 0.
+	for _, item := range items {
+		cumulativeWeight += item.weight
+		if cumulativeWeight >= rank {
+			return item.value
+		}
+	}
+
+	return items[len(items)-1].value
+}
+
+// encode - appends sketch to data as: offset, nofLevels, (levelSize, items...) for each level
+func (s *kllSketch) encode(data []float64) []float64 {
+	data = append(data, float64(s.offset), float64(len(s.compactors)))
+	for _, c := range s.compactors {
+		data = append(data, float64(len(c)))
+		data = append(data, c...)
+	}
+
+	return data
+}
+
+// decodeKllSketch - decodes sketch from data, returns sketch and remaining data
+func decodeKllSketch(data []float64, k int) (*kllSketch, []float64) {
+	s := &kllSketch{k: k, offset: int(data[0])}
+	nofLevels := int(data[1])
+	data = data[2:]
+	for h := 0; h < nofLevels; h++ {
+		s.grow()
+		levelSize := int(data[0])
+		s.compactors[h] = append(s.compactors[h], data[1:1+levelSize]...)
+		data = data[1+levelSize:]
+	}
+	s.updateSize()
+
+	return s, data
+}
+
+// exactQuantile - returns q-quantile (nearest rank) of given values
+func exactQuantile(values []float64, q float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	i := int(math.Ceil(q*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+
+	return sorted[i]
+}
@@ -0,0 +1,78 @@
+package simulation
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// rankError - returns difference between normalized rank of estimate and q in permutation of 1..n
+func rankError(estimate float64, q float64, n int) float64 {
+	return math.Abs(estimate/float64(n) - q)
+}
+
+func TestKllQuantileError(t *testing.T) {
+	tests := []struct {
+		k int
+		n int
+	}{
+		{k: 200, n: 100},
+		{k: 200, n: 10000},
+		{k: 200, n: 200000},
+		{k: 50, n: 50000},
+	}
+
+	for _, test := range tests {
+		s := newKllSketch(test.k)
+		rng := rand.New(rand.NewSource(1))
+		for i := 0; i < test.n; i++ {
+			// values 1..n in scrambled order
+			s.Update(float64((i*7919)%test.n+1), rng)
+		}
+
+		for _, q := range []float64{0.01, 0.25, 0.5, 0.75, 0.99} {
+			if e := rankError(s.Quantile(q), q, test.n); e > 4/float64(test.k) {
+				t.Errorf("k=%d, n=%d: rank error of %v-quantile is %.4f", test.k, test.n, q, e)
+			}
+		}
+	}
+}
+
+func TestKllMergeKeepsLevelsBounded(t *testing.T) {
+	n := 5000
+	s := newKllSketch(200)
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < n; i++ {
+		s.Update(float64((i*7919)%n+1), rng)
+	}
+
+	// merging sketch with itself doubles weights of all items, like gossip over many walks
+	for i := 0; i < 300; i++ {
+		copied, _ := decodeKllSketch(s.encode(nil), s.k)
+		s.Merge(copied, rng)
+	}
+
+	if len(s.compactors) > kllMaxLevels {
+		t.Errorf("sketch has %d levels, at most %d expected", len(s.compactors), kllMaxLevels)
+	}
+	if e := rankError(s.Quantile(0.5), 0.5, n); e > 0.05 {
+		t.Errorf("rank error of median after merges is %.4f", e)
+	}
+}
+
+func TestKllEncodeDecode(t *testing.T) {
+	s := newKllSketch(20)
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		s.Update(float64(i), rng)
+	}
+	s.trimTo(len(s.compactors) - 1)
+
+	decoded, rest := decodeKllSketch(s.encode([]float64{}), s.k)
+	if len(rest) != 0 || decoded.offset != s.offset || decoded.size != s.size {
+		t.Fatalf("decoded sketch differs: offset %d/%d, size %d/%d", decoded.offset, s.offset, decoded.size, s.size)
+	}
+	if decoded.Quantile(0.5) != s.Quantile(0.5) {
+		t.Errorf("decoded median %v differs from %v", decoded.Quantile(0.5), s.Quantile(0.5))
+	}
+}
@@ -0,0 +1,173 @@
+package simulation
+
+import (
+	"app/simulationGraph"
+	"app/utils"
+	"fmt"
+	"github.com/Knetic/govaluate"
+	"math"
+)
+
+// linkReliability - probabilities of link failures used by reliability models
+type linkReliability interface {
+	// nextRound - called by reliability model before edges are updated in a round
+	nextRound()
+	// probability - returns probability of failure of link (v, w) in current round
+	probability(v, w int) float64
+}
+
+// staticReliability - probabilities evaluated when graph was built (or read from graph file)
+type staticReliability struct {
+	relMap map[int]map[int]float64
+}
+
+func (s staticReliability) nextRound() {}
+
+func (s staticReliability) probability(v, w int) float64 {
+	return s.relMap[v][w]
+}
+
+// reliabilityVariables - variables of time-varying reliability expression, besides them expression
+// can use attributes of edges given in graph file
+var reliabilityVariables = map[string]struct{}{
+	"r": {}, "n": {}, "deg_u": {}, "deg_v": {}, "dist_from_root": {}, "reliability": {},
+}
+
+// reliabilityExpression - probability of link failure evaluated in every round from expression with
+// variables r (round), n (number of stations), deg_u, deg_v (degrees of link ends u < v in original
+// topology), dist_from_root (distance of nearer link end from root in original topology, -1 if not
+// reachable), reliability (probability from graph) and attributes of link from graph file.
+// Values outside [0,1] are clamped (values which are not numbers are replaced by 0) and counted
+type reliabilityExpression struct {
+	expr       string
+	expression *govaluate.EvaluableExpression
+	g          *simulationGraph.GraphWrapper
+	state      expressionState
+	degrees    []int
+	distances  []int
+	parameters map[string]interface{}
+}
+
+// expressionState - round of reliability expression and counters of invalid values (saved in checkpoints)
+type expressionState struct {
+	Round      int
+	NofInvalid int
+	// FirstInvalid - description of the first invalid value
+	FirstInvalid string
+}
+
+func newReliabilityExpression(expr string, g *simulationGraph.GraphWrapper, root int) (*reliabilityExpression, error) {
+	expression, err := utils.ParseExpression(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reliability expression %q: %v", expr, err)
+	}
+	n := g.GraphStructure.Order()
+	if root < 0 || root >= n {
+		return nil, fmt.Errorf("reliability root %d is not a station", root)
+	}
+
+	edges := sortedEdges(g)
+	for _, e := range edges {
+		for name := range g.GetEdgeAttributes(e[0], e[1]) {
+			if _, ok := reliabilityVariables[name]; ok {
+				return nil, fmt.Errorf("attribute %s of edge [%d, %d] has name of reliability expression variable", name, e[0], e[1])
+			}
+		}
+	}
+	for _, name := range expression.Vars() {
+		if _, ok := reliabilityVariables[name]; ok {
+			continue
+		}
+		for _, e := range edges {
+			if _, ok := g.GetEdgeAttributes(e[0], e[1])[name]; !ok {
+				return nil, fmt.Errorf("variable %s of reliability expression is not defined for edge [%d, %d]", name, e[0], e[1])
+			}
+		}
+	}
+
+	neighbours := g.GetOriginalNeighbours()
+	degrees := make([]int, n)
+	for v := range neighbours {
+		degrees[v] = len(neighbours[v])
+	}
+
+	return &reliabilityExpression{expr: expr, expression: expression, g: g, degrees: degrees,
+		distances: bfsDistances(neighbours, root), parameters: map[string]interface{}{"n": float64(n)}}, nil
+}
+
+// bfsDistances - returns distances from root (-1 if vertex is not reachable)
+func bfsDistances(neighbours [][]int, root int) []int {
+	distances := make([]int, len(neighbours))
+	for v := range distances {
+		distances[v] = -1
+	}
+	distances[root] = 0
+	queue := []int{root}
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		for _, w := range neighbours[v] {
+			if distances[w] < 0 {
+				distances[w] = distances[v] + 1
+				queue = append(queue, w)
+			}
+		}
+	}
+
+	return distances
+}
+
+func (e *reliabilityExpression) nextRound() {
+	e.state.Round++
+	e.parameters["r"] = float64(e.state.Round)
+}
+
+func (e *reliabilityExpression) probability(v, w int) float64 {
+	if v > w {
+		v, w = w, v
+	}
+	dist := e.distances[v]
+	if dist < 0 || (e.distances[w] >= 0 && e.distances[w] < dist) {
+		dist = e.distances[w]
+	}
+
+	e.parameters["deg_u"] = float64(e.degrees[v])
+	e.parameters["deg_v"] = float64(e.degrees[w])
+	e.parameters["dist_from_root"] = float64(dist)
+	e.parameters["reliability"] = e.g.GetRelMap()[v][w]
+	for name, value := range e.g.GetEdgeAttributes(v, w) {
+		e.parameters[name] = value
+	}
+
+	result, err := e.expression.Evaluate(e.parameters)
+	p, ok := result.(float64)
+	if err != nil || !ok || math.IsNaN(p) || p < 0 || p > 1 {
+		if e.state.NofInvalid == 0 {
+			e.state.FirstInvalid = fmt.Sprintf("%v for edge [%d, %d] in round %d", result, v, w, e.state.Round)
+		}
+		e.state.NofInvalid++
+		if !ok || err != nil || math.IsNaN(p) {
+			p = 0
+		}
+		p = math.Min(math.Max(p, 0), 1)
+	}
+
+	return p
+}
+
+// stats - returns expression and number of its values which were not valid probabilities
+func (e *reliabilityExpression) stats() map[string]interface{} {
+	return map[string]interface{}{
+		"expression":                e.expr,
+		"nof_invalid_probabilities": e.state.NofInvalid,
+		"first_invalid_probability": e.state.FirstInvalid,
+	}
+}
+
+// expressionStats - returns statistics of time-varying reliability expression, nil for static probabilities
+func expressionStats(reliability linkReliability) map[string]interface{} {
+	if e, ok := reliability.(*reliabilityExpression); ok {
+		return e.stats()
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package simulation
+
+import (
+	"app/simulationGraph"
+	"math"
+	"testing"
+)
+
+// attributedGraph - path 0-1-2-3 and separate edge 4-5, edges have attribute length
+func attributedGraph(attribute string) *simulationGraph.GraphWrapper {
+	edge := func(v, w uint, length float64) simulationGraph.JsonEdge {
+		return simulationGraph.JsonEdge{Edge: []uint{v, w}, Reliability: 0.5, Attributes: map[string]float64{attribute: length}}
+	}
+	return simulationGraph.BuildGraphFromConfig(simulationGraph.JsonGraphStructure{Graph: simulationGraph.JsonGraph{
+		NofVertices: 6,
+		Edges:       []simulationGraph.JsonEdge{edge(0, 1, 2), edge(1, 2, 3), edge(2, 3, 1), edge(4, 5, 4)},
+	}})
+}
+
+func TestReliabilityExpressionVariables(t *testing.T) {
+	tests := []struct {
+		expr string
+		// expected - probabilities of edges 0-1, 1-2, 2-3 and 4-5 in the second round
+		expected [4]float64
+	}{
+		{"r/10", [4]float64{0.2, 0.2, 0.2, 0.2}},
+		{"n/100", [4]float64{0.06, 0.06, 0.06, 0.06}},
+		{"deg_u/10 + deg_v/100", [4]float64{0.12, 0.22, 0.21, 0.11}},
+		// root 1, distance of nearer end, -1 for edge unreachable from root
+		{"(dist_from_root+1)/10", [4]float64{0.1, 0.1, 0.2, 0}},
+		{"length/10", [4]float64{0.2, 0.3, 0.1, 0.4}},
+		{"reliability * r / 4", [4]float64{0.25, 0.25, 0.25, 0.25}},
+	}
+
+	g := attributedGraph("length")
+	for _, test := range tests {
+		e, err := newReliabilityExpression(test.expr, g, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.nextRound()
+		e.nextRound()
+		for i, edge := range [][2]int{{0, 1}, {2, 1}, {2, 3}, {4, 5}} {
+			if p := e.probability(edge[0], edge[1]); math.Abs(p-test.expected[i]) > 1e-9 {
+				t.Errorf("%s: probability of edge %v is %v, expected %v", test.expr, edge, p, test.expected[i])
+			}
+		}
+		if nofInvalid := e.stats()["nof_invalid_probabilities"]; nofInvalid != 0 {
+			t.Errorf("%s: %v invalid probabilities", test.expr, nofInvalid)
+		}
+	}
+}
+
+func TestReliabilityExpressionRejectsUndefinedNames(t *testing.T) {
+	tests := []struct {
+		expr      string
+		attribute string
+	}{
+		{"speed/10", "length"},
+		{"r/10", "r"},
+		{"length/10", "deg_u"},
+		{"length/10", "n"},
+	}
+
+	for _, test := range tests {
+		if _, err := newReliabilityExpression(test.expr, attributedGraph(test.attribute), 0); err == nil {
+			t.Errorf("%s with attribute %s: expected error", test.expr, test.attribute)
+		}
+	}
+	if _, err := newReliabilityExpression("r/10", attributedGraph("length"), 6); err == nil {
+		t.Errorf("expected error for root which is not a station")
+	}
+}
+
+func TestReliabilityExpressionClampsInvalidValues(t *testing.T) {
+	e, err := newReliabilityExpression("r/2 - 0.5", attributedGraph("length"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// rounds 1..4 give 0, 0.5, 1 and 1.5 for every edge
+	expected := []float64{0, 0.5, 1, 1}
+	for round, p := range expected {
+		e.nextRound()
+		if got := e.probability(0, 1); got != p {
+			t.Errorf("round %d: expected probability %v, got %v", round+1, p, got)
+		}
+	}
+	e.probability(4, 5)
+
+	stats := e.stats()
+	if stats["nof_invalid_probabilities"] != 2 || stats["first_invalid_probability"] != "1.5 for edge [0, 1] in round 4" {
+		t.Errorf("unexpected stats of invalid values %v", stats)
+	}
+}
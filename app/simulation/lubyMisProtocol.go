@@ -0,0 +1,173 @@
+package simulation
+
+import (
+	"math"
+)
+
+const (
+	misUndecided = iota
+	misIn
+	misOut
+)
+
+// LubyMisProtocol - Luby's maximal independent set, each phase takes two rounds: undecided stations
+// exchange random values and local minima join the set, then neighbours of joined stations drop out
+type LubyMisProtocol struct {
+	nofPhases int // 0 - chosen automatically based on number of stations
+}
+
+// lubyMisState - state of station in Luby's algorithm
+type lubyMisState struct {
+	mis    int  // misUndecided, misIn or misOut
+	joined bool // station joined the set in current phase and notifies neighbours
+}
+
+func init() {
+	RegisterProtocol(ProtocolInfo{
+		Name:        "lubyMis",
+		Description: "compute maximal independent set using Luby's algorithm",
+		Parameters: []ProtocolParameter{
+			{Name: "phases", Type: IntParameter, Default: "0", Description: "number of phases (0 - 4*log2(n)+1)"},
+		},
+		Factory: func(params ProtocolParams) (Protocol, error) {
+			return WithState[lubyMisState](LubyMisProtocol{nofPhases: params.Int("phases")}), nil
+		},
+	})
+}
+
+// randomizedPhases - number of two-round phases run by randomized symmetry breaking protocols
+func randomizedPhases(nofPhases int, station IStation) int {
+	if nofPhases > 0 {
+		return nofPhases
+	}
+	n := station.GetGraph().GraphStructure.Order()
+	return 4*int(math.Ceil(math.Log2(float64(n)+1))) + 1
+}
+
+func (LubyMisProtocol) GetInitialData(station IStation, state *lubyMisState) {
+	station.SetCurrentData([]float64{station.GetRandom().Float64()})
+	state.mis = misUndecided
+}
+
+func (LubyMisProtocol) OnInitialize(station IStation, state *lubyMisState) {
+	station.Broadcast()
+}
+
+func (LubyMisProtocol) OnDataReceive(station IStation, state *lubyMisState) {
+	mq := station.GetMsgQueue()
+	if station.GetRoundCounter()%2 == 0 {
+		// values of undecided neighbours, the smallest one joins (ties broken by id)
+		value := station.GetCurrentData()[0]
+		localMin := true
+		for mq.Len() > 0 {
+			msg := mq.Dequeue()
+			if msg.Data[0] < value || (msg.Data[0] == value && msg.SenderId < station.GetId()) {
+				localMin = false
+			}
+		}
+		if state.mis == misUndecided && localMin {
+			state.mis = misIn
+			state.joined = true
+		}
+		return
+	}
+
+	// notifications from neighbours which joined the set
+	for mq.Len() > 0 {
+		mq.Dequeue()
+		if state.mis == misUndecided {
+			state.mis = misOut
+		}
+	}
+}
+
+func (LubyMisProtocol) OnDataPropagate(station IStation, state *lubyMisState) {
+	if station.GetRoundCounter()%2 == 0 {
+		if state.joined {
+			state.joined = false
+			station.SynchronizedBroadcast()
+		}
+		return
+	}
+
+	if state.mis == misUndecided {
+		station.SetCurrentData([]float64{station.GetRandom().Float64()})
+		station.SynchronizedBroadcast()
+	}
+}
+
+func (p LubyMisProtocol) StopCondition(station IStation, state *lubyMisState) bool {
+	return station.GetRoundCounter() < 2*randomizedPhases(p.nofPhases, station)
+}
+
+func (LubyMisProtocol) OnFinalize(station IStation, state *lubyMisState) {
+	switch state.mis {
+	case misIn:
+		station.SetResult(1)
+	case misOut:
+		station.SetResult(0)
+	default:
+		station.SetResult(-1)
+	}
+}
+
+func (LubyMisProtocol) CalculateStationExactResult(station IStation, state *lubyMisState) float64 {
+	return -1
+}
+
+// CalculateGlobalExactResult - returns 1 if computed set is maximal independent set, 0 otherwise
+func (p LubyMisProtocol) CalculateGlobalExactResult(stations *[]IStation) float64 {
+	report := p.checkValidity(stations)
+	return report.valid()
+}
+
+func (p LubyMisProtocol) CalculateProtocolStats(stations *[]IStation) map[string]interface{} {
+	report := p.checkValidity(stations)
+	stats := report.stats()
+	misSize := 0
+	for _, station := range *stations {
+		if StateOf[lubyMisState](station).mis == misIn {
+			misSize++
+		}
+	}
+	stats["mis_size"] = misSize
+
+	return stats
+}
+
+// checkValidity - checks independence and maximality on original graph topology
+func (LubyMisProtocol) checkValidity(stations *[]IStation) *validityReport {
+	report := &validityReport{}
+	states := make([]int, len(*stations))
+	for _, station := range *stations {
+		states[station.GetId()] = StateOf[lubyMisState](station).mis
+	}
+
+	dominated := make([]bool, len(states))
+	for v, neighbours := range (*stations)[0].GetGraph().GetOriginalNeighbours() {
+		for _, w := range neighbours {
+			if w < v {
+				continue
+			}
+			if states[v] == misIn && states[w] == misIn {
+				report.addViolation("independence", v, w)
+			}
+			if states[v] == misIn {
+				dominated[w] = true
+			}
+			if states[w] == misIn {
+				dominated[v] = true
+			}
+		}
+	}
+
+	for v, state := range states {
+		if state == misUndecided {
+			report.addViolation("undecided", v)
+		} else if state == misOut && !dominated[v] {
+			report.addViolation("maximality", v)
+		}
+	}
+
+	return report
+}
@@ -0,0 +1,45 @@
+package simulation
+
+import (
+	"app/simulationGraph"
+	"testing"
+)
+
+// misTestGraphs - graphs on which MIS and coloring are checked, diameters are given for runs
+var misTestGraphs = []struct {
+	name     string
+	g        func() *simulationGraph.GraphWrapper
+	diameter int
+}{
+	{"path", func() *simulationGraph.GraphWrapper { return simulationGraph.BuildPath(9, "", "0") }, 8},
+	{"grid", func() *simulationGraph.GraphWrapper { return simulationGraph.BuildGrid(5, 5, "", "0") }, 8},
+	{"clique", func() *simulationGraph.GraphWrapper { return simulationGraph.BuildClique(6, "", "0") }, 1},
+	{"hypercube", func() *simulationGraph.GraphWrapper { return simulationGraph.BuildHyperCube(4, "", "0") }, 4},
+}
+
+func TestLubyMisIsIndependentAndMaximal(t *testing.T) {
+	for _, test := range misTestGraphs {
+		g := test.g()
+		result := runOnGraph(t, "lubyMis", "", g, test.diameter)
+		in := make([]bool, len(result.Stations))
+		for _, station := range result.Stations {
+			if station.Result != 0 && station.Result != 1 {
+				t.Fatalf("%s: station %d is undecided", test.name, station.GetId())
+			}
+			in[station.GetId()] = station.Result == 1
+		}
+
+		for v, neighbours := range g.GetOriginalNeighbours() {
+			dominated := in[v]
+			for _, w := range neighbours {
+				if in[v] && in[w] {
+					t.Errorf("%s: adjacent stations %d and %d are both in MIS", test.name, v, w)
+				}
+				dominated = dominated || in[w]
+			}
+			if !dominated {
+				t.Errorf("%s: station %d and all its neighbours are outside MIS", test.name, v)
+			}
+		}
+	}
+}
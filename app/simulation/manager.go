@@ -3,17 +3,63 @@ package simulation
 import (
 	"app/simulationGraph"
 	"app/threading/barrier"
+	"app/utils"
+	"fmt"
 	"github.com/montanaflynn/stats"
+	"math/rand"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type Manager struct {
-	nofStations       int
-	graph             *simulationGraph.GraphWrapper
-	stations          *[]IStation
-	nofActiveStations int
-	reliabilityModel  string
-	b                 *barrier.Barrier
+	nofStations      int
+	graph            *simulationGraph.GraphWrapper
+	stations         *[]IStation
+	reliabilityModel string
+	b                *barrier.Barrier
+	// running - stations which have not stopped yet, allStopped is closed when all of them stopped
+	running    *sync.WaitGroup
+	allStopped chan bool
+
+	termination Termination
+	engine      string
+	nofWorkers  int
+	// roundSentMsgs, stateChanged - activity in current round, updated atomically by stations
+	roundSentMsgs int64
+	stateChanged  int32
+	stableRounds  int
+	// terminated - decision of engine level termination, valid between barriers
+	terminated bool
+
+	observers *observers
+	// round - number of current round, changed only when no station is running
+	round     int
+	roundOpen bool
+	// pendingChanges - edge changes made by reliability model before current round started
+	pendingChanges []EdgeChange
+	// seed - seed of random generators of stations and reliability model
+	seed int64
+	// customUpdater - edge updater used instead of reliability model
+	customUpdater IEdgeUpdater
+	// updater - edge updater used in simulation
+	updater IEdgeUpdater
+	// scenario - updater applying scenario of topology events after reliability model
+	scenario *scenarioUpdater
+	// reliability - time-varying probabilities of link failures, nil if probabilities are static
+	reliability *reliabilityExpression
+	// orderInbox - optional function ordering messages received by station in a round (sequential engine)
+	orderInbox func(round int, receiverId int, inbox []*Pack)
+	// updaterSource - source of random generator used by reliability model
+	updaterSource *countingSource
+
+	// checkpointPath, checkpointEvery, runConfig - checkpointing of sequential engine (every 0 - disabled)
+	checkpointPath  string
+	checkpointEvery int
+	runConfig       RunConfig
+	checkpointErr   error
+	// resumeFrom - checkpoint from which simulation is continued
+	resumeFrom *checkpoint
 }
 
 func NewManager(reliabilityModel string, graph *simulationGraph.GraphWrapper) *Manager {
@@ -25,7 +71,15 @@ func NewManager(reliabilityModel string, graph *simulationGraph.GraphWrapper) *M
 		stations:         &stations,
 		graph:            graph,
 		reliabilityModel: reliabilityModel,
-		b:                b}
+		b:                b,
+		running:          &sync.WaitGroup{},
+		allStopped:       make(chan bool),
+		termination:      Termination{Mode: TerminationByProtocol},
+		engine:           GoroutineEngine,
+		nofWorkers:       1,
+		observers:        &observers{list: make([]Observer, 0), mutex: &sync.Mutex{}},
+		seed:             time.Now().UnixNano()}
+	b.SetAction(manager.advanceRound)
 
 	for i := 0; i < nofVertices; i++ {
 		stations = append(stations, NewSynchronousStation(manager, i, graph))
@@ -34,42 +88,225 @@ func NewManager(reliabilityModel string, graph *simulationGraph.GraphWrapper) *M
 	return manager
 }
 
-func (m Manager) RunSimulation(protocolName string) JsonStatsStructure {
+// SetTermination - sets global termination mode of simulation
+func (m *Manager) SetTermination(termination Termination) {
+	m.termination = termination
+}
+
+// SetEngine - sets simulation engine (goroutines|sequential), sequential engine processes
+// stations with given number of workers
+func (m *Manager) SetEngine(engine string, nofWorkers int) error {
+	if engine != GoroutineEngine && engine != SequentialEngine {
+		return fmt.Errorf("unknown engine %q (%s|%s)", engine, GoroutineEngine, SequentialEngine)
+	}
+	if nofWorkers <= 0 {
+		return fmt.Errorf("number of workers should be positive, got %d", nofWorkers)
+	}
+
+	m.engine = engine
+	m.nofWorkers = nofWorkers
+	for i := range *m.stations {
+		if engine == SequentialEngine {
+			(*m.stations)[i] = NewSequentialStation(m, i, m.graph)
+		} else {
+			(*m.stations)[i] = NewSynchronousStation(m, i, m.graph)
+		}
+	}
+
+	return nil
+}
+
+// SetSeed - sets seed of random generators of stations and reliability model
+func (m *Manager) SetSeed(seed int64) {
+	m.seed = seed
+}
+
+// SetEdgeUpdater - sets edge updater used instead of reliability model and scenario
+func (m *Manager) SetEdgeUpdater(updater IEdgeUpdater) {
+	m.customUpdater = updater
+}
+
+// SetReliabilityExpression - sets time-varying probability expression evaluated by reliability model for every
+// link in every round, root is used by dist_from_root variable, static expressions (using only n) are ignored,
+// they are evaluated when graph is built
+func (m *Manager) SetReliabilityExpression(expr string, root int) error {
+	if !utils.IsTimeVarying(expr) {
+		return nil
+	}
+
+	reliability, err := newReliabilityExpression(expr, m.graph, root)
+	if err != nil {
+		return err
+	}
+	m.reliability = reliability
+	return nil
+}
+
+// SetScenario - sets scenario of topology events, they are applied after updates of reliability model
+func (m *Manager) SetScenario(scenario *Scenario) error {
+	updater, err := newScenarioUpdater(scenario, m.graph)
+	if err != nil {
+		return err
+	}
+	m.scenario = updater
+	return nil
+}
+
+// edgeUpdater - returns edge updater used in simulation, nil if edges are not updated
+func (m *Manager) edgeUpdater() IEdgeUpdater {
+	if m.updater == nil {
+		if m.customUpdater != nil {
+			m.updater = m.customUpdater
+		} else if model := m.getReliabilityModel(m.reliabilityModel); m.scenario == nil {
+			m.updater = model
+		} else if model == nil {
+			m.updater = m.scenario
+		} else {
+			m.updater = compositeUpdater{updaters: []IEdgeUpdater{model, m.scenario}}
+		}
+	}
+
+	return m.updater
+}
+
+// AddObserver - registers observer notified about simulation events
+func (m *Manager) AddObserver(observer Observer) {
+	m.observers.list = append(m.observers.list, observer)
+}
+
+// startRound - starts next round, notifies observers about its start and about edge changes preceding it
+func (m *Manager) startRound(round int) {
+	m.round = round
+	m.roundOpen = true
+	m.observers.notify(func(o Observer) { o.OnRoundStart(round) })
+	if len(m.pendingChanges) > 0 {
+		m.observers.notify(func(o Observer) { o.OnTopologyChanged(round, m.pendingChanges) })
+	}
+	m.pendingChanges = nil
+}
+
+// endRound - notifies observers that all stations finished current round
+func (m *Manager) endRound() {
+	if m.roundOpen {
+		m.roundOpen = false
+		m.observers.notify(func(o Observer) { o.OnRoundEnd(m.round) })
+	}
+}
+
+// advanceRound - called when all running stations reached first barrier (no station is running),
+// ends previous round and starts the next one unless simulation terminated
+func (m *Manager) advanceRound() {
+	m.decideTermination()
+	m.endRound()
+	if !m.terminated {
+		m.startRound(m.round + 1)
+	}
+}
+
+// decideTermination - called when all running stations reached first barrier, i.e. all messages
+// of previous round were sent, decides if simulation ends in engine level termination mode
+func (m *Manager) decideTermination() {
+	switch m.termination.Mode {
+	case TerminationByQuiescence:
+		m.terminated = atomic.SwapInt64(&m.roundSentMsgs, 0) == 0
+	case TerminationByStableState:
+		if atomic.SwapInt32(&m.stateChanged, 0) == 0 {
+			m.stableRounds++
+		} else {
+			m.stableRounds = 0
+		}
+		m.terminated = m.stableRounds >= m.termination.StableRounds
+	}
+}
+
+// continueRunning - returns true if station should run next round
+func (m *Manager) continueRunning(p Protocol, station IStation) bool {
+	if !m.termination.isEngineLevel() {
+		return p.StopCondition(station)
+	}
+
+	return station.GetRoundCounter() < m.termination.MaxRounds
+}
+
+// recordState - marks that station changed its state in current round (stable termination mode)
+// and notifies observers about the change, change is reported by protocol if it implements
+// StateChangeReporter, otherwise it is detected by comparing fingerprints of station's state
+func (m *Manager) recordState(p Protocol, station IStation, fingerprint *uint64) {
+	if m.termination.Mode != TerminationByStableState && !m.observers.active() {
+		return
+	}
+	changed := m.round == 0
+	if reporter, ok := p.(StateChangeReporter); ok {
+		changed = changed || reporter.StateChanged(station)
+	} else {
+		state := station.GetStation()
+		f := stateFingerprint(&state)
+		changed = f != *fingerprint
+		*fingerprint = f
+	}
+	if changed {
+		atomic.StoreInt32(&m.stateChanged, 1)
+		m.observers.notify(func(o Observer) { o.OnStationStateChanged(m.round, station) })
+	}
+}
+
+// RunSimulation - runs given protocol on all stations and returns statistics
+func (m *Manager) RunSimulation(p Protocol) JsonStatsStructure {
+	if m.engine == SequentialEngine {
+		return m.runSequential(p)
+	}
+
 	var wg sync.WaitGroup
-	p := m.mapNameToProtocol(protocolName)
 	wg.Add(len(*m.stations))
 	updateBeginChannel := make(chan bool, 1)
 	updateFinishChannel := make(chan bool, 1)
 	done := make(chan bool)
-	updater := m.getReliabilityModel(m.reliabilityModel, updateBeginChannel, updateFinishChannel)
-
-	if m.reliabilityModel != "" {
-		go updater.RunEdgeUpdating(done)
+	updater := m.edgeUpdater()
+	if updater != nil {
+		go runEdgeUpdating(updater, len(*m.stations), updateBeginChannel, updateFinishChannel, done,
+			func(changes []EdgeChange) { m.pendingChanges = append(m.pendingChanges, changes...) })
 	}
 
+	m.startRound(0)
+	m.running.Add(len(*m.stations))
 	for _, s := range *m.stations {
-		go s.RunProtocol(p, &wg, m.reliabilityModel, updateBeginChannel, updateFinishChannel)
+		s.(*SynchronousStation).seedRandom(m.seed)
+	}
+	for _, s := range *m.stations {
+		go s.(*SynchronousStation).RunProtocol(p, &wg, updater != nil, updateBeginChannel, updateFinishChannel)
 	}
 
+	// stations finalize only after all of them stopped, so the graph is no longer modified
+	m.running.Wait()
+	m.endRound()
+	close(done)
+	close(m.allStopped)
 	wg.Wait()
-	if m.reliabilityModel != "" {
-		done <- true
-	}
 	m.b.Close()
 	close(updateBeginChannel)
 	close(updateFinishChannel)
 	return m.makeStatsSummary(p)
 }
 
-func (m Manager) getStationById(id int) IStation {
+func (m *Manager) getStationById(id int) IStation {
 	return (*m.stations)[id]
 }
 
-func (m Manager) getReliabilityModel(reliabilityModel string, updateBeginChannel, updateFinishChannel chan bool) IEdgeUpdater {
+func (m *Manager) getReliabilityModel(reliabilityModel string) IEdgeUpdater {
+	// updater has its own generator, so it does not disturb random values drawn by protocol
+	m.updaterSource = newCountingSource(m.seed ^ 0x5DEECE66D)
+	rng := rand.New(m.updaterSource)
+	var reliability linkReliability = staticReliability{relMap: m.graph.GetRelMap()}
+	if m.reliability != nil {
+		reliability = m.reliability
+	}
+
 	if reliabilityModel == "edge-remover" {
-		return newEdgeRemover(m.graph, updateBeginChannel, updateFinishChannel)
+		return newEdgeRemover(m.graph, rng, reliability)
 	} else if reliabilityModel == "edge-remover-adder" {
-		return newEdgeRemoverAdder(m.graph, updateBeginChannel, updateFinishChannel)
+		return newEdgeRemoverAdder(m.graph, rng, reliability)
+	} else if reliabilityModel == "gilbert-elliott" {
+		return newGilbertElliott(m.graph, rng, reliability)
 	}
 	return nil
 }
@@ -80,11 +317,13 @@ func (m Manager) makeStatsSummary(p Protocol) JsonStatsStructure {
 	msgsSentStats := make([]float64, 0)
 	msgsReceivedStats := make([]float64, 0)
 	roundsStats := make([]float64, 0)
+	allUndeliveredMsgs := 0
 	memoryStats := make([]float64, 0)
 
 	for _, station := range *m.stations {
 		msgsSentStats = append(msgsSentStats, float64(station.GetSentMsgCounter()))
 		msgsReceivedStats = append(msgsReceivedStats, float64(station.GetReceivedMsgCounter()))
+		allUndeliveredMsgs += station.GetUndeliveredMsgCounter()
 		roundsStats = append(roundsStats, float64(station.GetRoundCounter()))
 		memoryStats = append(memoryStats, float64(station.GetMemoryCounter()))
 		stations = append(stations, station.GetStation())
@@ -123,6 +362,7 @@ func (m Manager) makeStatsSummary(p Protocol) JsonStatsStructure {
 		AllSentMsgs:        int(allSentMsgs),
 		AvgSentMsgs:        avgSentMsgs,
 		StddevSentMsgs:     stddevSentMsgs,
+		AllUndeliveredMsgs: allUndeliveredMsgs,
 		AllMemory:          int(allMemory),
 		MaxMemory:          int(maxMemory),
 		MinMemory:          int(minMemory),
@@ -131,5 +371,12 @@ func (m Manager) makeStatsSummary(p Protocol) JsonStatsStructure {
 		Stations:           stations,
 	}
 
+	if statsProvider, ok := p.(ProtocolStatsProvider); ok {
+		statistics.ProtocolStats = statsProvider.CalculateProtocolStats(m.stations)
+	}
+	if statsProvider, ok := m.updater.(ReliabilityStatsProvider); ok {
+		statistics.ReliabilityStats = statsProvider.CalculateReliabilityStats()
+	}
+
 	return statistics
 }
@@ -1,10 +0,0 @@
-package simulation
-
-func (m Manager) mapNameToProtocol(name string) Protocol {
-	if name == "hll" {
-		return HllProtocol{}
-	} else if name == "minPropagation" {
-		return MinPropagationProtocol{}
-	}
-	return nil
-}
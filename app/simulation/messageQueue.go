@@ -12,6 +12,15 @@ func (m *MessageQueue) Len() int {
 	return len(m.queue)
 }
 
+// DataLen - returns number of float64 values in queued messages
+func (m *MessageQueue) DataLen() int {
+	dataLen := 0
+	for _, msg := range m.queue {
+		dataLen += len(msg.Data)
+	}
+	return dataLen
+}
+
 func (m *MessageQueue) Enqueue(msgPack *Pack) {
 	m.queue = append(m.queue, msgPack)
 }
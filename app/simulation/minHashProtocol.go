@@ -0,0 +1,301 @@
+package simulation
+
+import (
+	"app/hashing"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// MinHashProtocol - Jaccard similarity estimation with MinHash signatures (k hash functions).
+// In "union" mode signatures are min-merged like in MinPropagationProtocol and every station estimates
+// similarity of its set with network-wide union, in "pairwise" mode stations flood signatures of other
+// stations (each signature is forwarded once) and estimate similarity with every other station
+type MinHashProtocol struct {
+	valueObserver
+	k        int
+	pairwise bool
+}
+
+// minHashState - state of station estimating Jaccard similarity
+type minHashState struct {
+	// signatures - signatures of stations known to station (by id), in union mode only its own one
+	signatures        map[int][]float64
+	signaturesChanged bool
+}
+
+func init() {
+	parameters := []ProtocolParameter{
+		{Name: "k", Type: IntParameter, Default: "64", Description: "number of hash functions (signature length)"},
+		{Name: "target", Type: StringParameter, Default: "union", Description: "union|pairwise"},
+	}
+	for _, p := range observationParameters() {
+		if p.Name == "max" {
+			// small range, so that sets of stations overlap
+			p.Default = "200"
+		}
+		parameters = append(parameters, p)
+	}
+
+	RegisterProtocol(ProtocolInfo{
+		Name:        "minHash",
+		Description: "estimate Jaccard similarity of observed sets using MinHash signatures",
+		Parameters:  parameters,
+		Factory: func(params ProtocolParams) (Protocol, error) {
+			observer, err := newValueObserver(params)
+			if err != nil {
+				return nil, err
+			}
+
+			p := MinHashProtocol{valueObserver: observer, k: params.Int("k")}
+			if p.k <= 0 {
+				return nil, fmt.Errorf("k should be positive, got %d", p.k)
+			}
+			switch params.String("target") {
+			case "union":
+			case "pairwise":
+				p.pairwise = true
+			default:
+				return nil, fmt.Errorf("unknown minHash target %q (union|pairwise)", params.String("target"))
+			}
+
+			return WithState[minHashState](p), nil
+		},
+	})
+}
+
+// signature - MinHash signature of given values, hashes are truncated to 53 bits to fit in float64
+func (p MinHashProtocol) signature(values [][]byte) []float64 {
+	signature := make([]float64, p.k)
+	for i := range signature {
+		signature[i] = math.Inf(1)
+	}
+	for _, b := range values {
+		h1, h2 := hashing.XXHash64(b), hashing.Murmur3(b)
+		for i := range signature {
+			h := float64((h1 + uint64(i)*h2) >> 11)
+			if h < signature[i] {
+				signature[i] = h
+			}
+		}
+	}
+
+	return signature
+}
+
+// jaccard - estimates Jaccard similarity as fraction of equal signature elements
+func jaccard(a, b []float64) float64 {
+	equal := 0
+	for i := range a {
+		if a[i] == b[i] {
+			equal++
+		}
+	}
+
+	return float64(equal) / float64(len(a))
+}
+
+func (p MinHashProtocol) GetInitialData(station IStation, state *minHashState) {
+	signature := p.signature(p.observeValues(station))
+	state.signatures = map[int][]float64{station.GetId(): signature}
+
+	if p.pairwise {
+		// message data: (stationId, signature) for each newly learned signature
+		station.SetCurrentData(append([]float64{float64(station.GetId())}, signature...))
+	} else {
+		station.SetCurrentData(append([]float64{}, signature...))
+	}
+}
+
+func (MinHashProtocol) OnInitialize(station IStation, state *minHashState) {
+	station.Broadcast()
+}
+
+func (p MinHashProtocol) OnDataReceive(station IStation, state *minHashState) {
+	changed := false
+	mq := station.GetMsgQueue()
+	if p.pairwise {
+		signatures := state.signatures
+		newSignatures := make([]float64, 0)
+		for mq.Len() > 0 {
+			data := mq.Dequeue().Data
+			for ; len(data) > 0; data = data[1+p.k:] {
+				id := int(data[0])
+				if _, ok := signatures[id]; !ok {
+					signatures[id] = data[1 : 1+p.k]
+					newSignatures = append(newSignatures, data[:1+p.k]...)
+				}
+			}
+		}
+		if changed = len(newSignatures) > 0; changed {
+			station.SetCurrentData(newSignatures)
+		}
+	} else {
+		// copy, current data may still be read by neighbours
+		current := append([]float64{}, station.GetCurrentData()...)
+		for mq.Len() > 0 {
+			for i, h := range mq.Dequeue().Data {
+				if h < current[i] {
+					current[i] = h
+					changed = true
+				}
+			}
+		}
+		if changed {
+			station.SetCurrentData(current)
+		}
+	}
+
+	state.signaturesChanged = changed
+}
+
+func (MinHashProtocol) OnDataPropagate(station IStation, state *minHashState) {
+	if state.signaturesChanged {
+		station.SynchronizedBroadcast()
+	}
+}
+
+func (MinHashProtocol) StopCondition(station IStation, state *minHashState) bool {
+	return station.GetRoundCounter() < station.GetGraph().GetDiameter()
+}
+
+// OnFinalize - result is similarity with union or mean similarity with other stations
+func (p MinHashProtocol) OnFinalize(station IStation, state *minHashState) {
+	station.SetResult(meanOf(p.estimatedSimilarities(station)))
+}
+
+// estimatedSimilarities - similarity with union or similarities with known stations (by id)
+func (p MinHashProtocol) estimatedSimilarities(station IStation) map[int]float64 {
+	signatures := StateOf[minHashState](station).signatures
+	own := signatures[station.GetId()]
+	if !p.pairwise {
+		return map[int]float64{-1: jaccard(own, station.GetCurrentData())}
+	}
+
+	similarities := map[int]float64{}
+	for id, signature := range signatures {
+		if id != station.GetId() {
+			similarities[id] = jaccard(own, signature)
+		}
+	}
+
+	return similarities
+}
+
+// meanOf - returns mean of values, values are summed in order of keys, so the result does not depend on map order
+func meanOf(values map[int]float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.
+	for _, key := range sortedIds(values) {
+		sum += values[key]
+	}
+
+	return sum / float64(len(values))
+}
+
+func (MinHashProtocol) CalculateStationExactResult(station IStation, state *minHashState) float64 {
+	return -1
+}
+
+// sortedIds - returns station ids (keys of map) in ascending order
+func sortedIds(values map[int]float64) []int {
+	keys := make([]int, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+
+	return keys
+}
+
+// exactSimilarities - exact similarity of every station with union (-1) or with other stations
+func (p MinHashProtocol) exactSimilarities(stations *[]IStation) map[int]map[int]float64 {
+	sets := make(map[int]map[float64]struct{}, len(*stations))
+	for _, station := range *stations {
+		set := map[float64]struct{}{}
+		for _, v := range station.GetObservedValues() {
+			set[v[0]] = struct{}{}
+		}
+		sets[station.GetId()] = set
+	}
+
+	similarities := map[int]map[int]float64{}
+	union := exactUnion(stations)
+	for v, a := range sets {
+		similarities[v] = map[int]float64{}
+		if !p.pairwise {
+			similarities[v][-1] = float64(len(a)) / float64(len(union))
+			continue
+		}
+		for w, b := range sets {
+			if v == w {
+				continue
+			}
+			intersection := 0
+			for x := range a {
+				if _, ok := b[x]; ok {
+					intersection++
+				}
+			}
+			similarities[v][w] = float64(intersection) / float64(len(a)+len(b)-intersection)
+		}
+	}
+
+	return similarities
+}
+
+// CalculateGlobalExactResult - mean exact similarity (with union or between pairs of stations)
+func (p MinHashProtocol) CalculateGlobalExactResult(stations *[]IStation) float64 {
+	sum, count := 0., 0
+	exact := p.exactSimilarities(stations)
+	for _, station := range *stations {
+		s := exact[station.GetId()]
+		for _, w := range sortedIds(s) {
+			sum += s[w]
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+
+	return sum / float64(count)
+}
+
+func (p MinHashProtocol) CalculateProtocolStats(stations *[]IStation) map[string]interface{} {
+	exact := p.exactSimilarities(stations)
+	sumError, maxError := 0., 0.
+	nofEstimates, missing := 0, 0
+	for _, station := range *stations {
+		estimated := p.estimatedSimilarities(station)
+		similarities := exact[station.GetId()]
+		// errors are summed in order of ids, so the result does not depend on map order
+		for _, w := range sortedIds(similarities) {
+			similarity := similarities[w]
+			estimate, ok := estimated[w]
+			if !ok {
+				missing++
+				continue
+			}
+			e := math.Abs(estimate - similarity)
+			sumError += e
+			maxError = math.Max(maxError, e)
+			nofEstimates++
+		}
+	}
+
+	avgError := 0.
+	if nofEstimates > 0 {
+		avgError = sumError / float64(nofEstimates)
+	}
+
+	return map[string]interface{}{
+		"avg_abs_error":       avgError,
+		"max_abs_error":       maxError,
+		"missing_estimates":   missing,
+		"std_error_bound":     0.5 / math.Sqrt(float64(p.k)),
+		"nof_estimated_pairs": nofEstimates,
+	}
+}
@@ -0,0 +1,53 @@
+package simulation
+
+import (
+	"app/simulationGraph"
+	"testing"
+)
+
+func TestMinHashEstimatesSimilarity(t *testing.T) {
+	graphs := []struct {
+		name     string
+		g        *simulationGraph.GraphWrapper
+		diameter int
+	}{
+		{"path", simulationGraph.BuildPath(5, "", "0"), 4},
+		{"grid", simulationGraph.BuildGrid(3, 3, "", "0"), 4},
+	}
+
+	for _, test := range graphs {
+		n := len(test.g.GetOriginalNeighbours())
+		for target, nofPairs := range map[string]int{"union": n, "pairwise": n * (n - 1)} {
+			result := runOnGraph(t, "minHash", "k=256,target="+target, test.g, test.diameter)
+			stats := result.ProtocolStats
+			if stats["missing_estimates"] != 0 || stats["nof_estimated_pairs"] != nofPairs {
+				t.Errorf("%s, %s: %v estimates, %v missing, expected %d estimates", test.name, target,
+					stats["nof_estimated_pairs"], stats["missing_estimates"], nofPairs)
+			}
+
+			bound := stats["std_error_bound"].(float64)
+			if avgError := stats["avg_abs_error"].(float64); avgError > bound {
+				t.Errorf("%s, %s: average error %v exceeds standard error bound %v", test.name, target, avgError, bound)
+			}
+			if maxError := stats["max_abs_error"].(float64); maxError > 4*bound {
+				t.Errorf("%s, %s: maximal error %v exceeds 4 standard error bounds %v", test.name, target, maxError, bound)
+			}
+		}
+	}
+}
+
+func TestMinHashIdenticalSets(t *testing.T) {
+	// all stations observe only value 1, so every similarity is exactly 1
+	g := simulationGraph.BuildGrid(3, 3, "", "0")
+	for _, target := range []string{"union", "pairwise"} {
+		result := runOnGraph(t, "minHash", "k=16,min=1,max=1,target="+target, g, 4)
+		for _, station := range result.Stations {
+			if station.Result != 1 {
+				t.Errorf("%s: station %d estimated similarity %v, expected 1", target, station.GetId(), station.Result)
+			}
+		}
+		if maxError := result.ProtocolStats["max_abs_error"].(float64); maxError != 0 {
+			t.Errorf("%s: maximal error %v for identical sets", target, maxError)
+		}
+	}
+}
@@ -2,14 +2,24 @@ package simulation
 
 import (
 	"math"
-	"math/rand"
 )
 
-// MinPropagationProtocol - extrema propagation protocol
+// MinPropagationProtocol - extrema propagation protocol, it implements Protocol directly and keeps
+// its minimum in user defined variable, so it stays an example of protocol without declared state
 type MinPropagationProtocol struct{}
 
+func init() {
+	RegisterProtocol(ProtocolInfo{
+		Name:        "minPropagation",
+		Description: "propagate minimum of exponentially distributed values",
+		Factory: func(params ProtocolParams) (Protocol, error) {
+			return MinPropagationProtocol{}, nil
+		},
+	})
+}
+
 func (MinPropagationProtocol) GetInitialData(station IStation) {
-	randValue := rand.ExpFloat64()
+	randValue := station.GetRandom().ExpFloat64()
 	data := []float64{randValue}
 	station.SetCurrentData(data)
 }
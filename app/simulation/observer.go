@@ -0,0 +1,83 @@
+package simulation
+
+import "sync"
+
+// Observer - interface used for instrumentation of simulation (tracers, live metrics, invariant checkers).
+// Round 0 is initialization (GetInitialData, OnInitialize), round r > 0 consists of receiving messages
+// sent in round r-1 and propagation. Callbacks are serialized by the engine, so observer does not have
+// to be thread-safe, but it must not call back into the simulation.
+type Observer interface {
+	// OnRoundStart - called when round starts, before any station processes it
+	OnRoundStart(round int)
+	// OnMessageSent - called when station sends message to neighbour
+	OnMessageSent(round int, senderId int, receiverId int, data []float64)
+	// OnMessageDelivered - called when message is put into receiver's message queue
+	OnMessageDelivered(round int, senderId int, receiverId int, data []float64)
+	// OnMessageDropped - called when message is discarded because receiver already stopped
+	OnMessageDropped(round int, senderId int, receiverId int, data []float64)
+	// OnStationStateChanged - called at the end of station's round if its state changed (always in round 0),
+	// change is reported by protocol implementing StateChangeReporter, otherwise it is detected
+	// by fingerprint of station's current data, user defined variables and typed state
+	OnStationStateChanged(round int, station IStation)
+	// OnTopologyChanged - called at the beginning of round when reliability model changed edges
+	OnTopologyChanged(round int, changes []EdgeChange)
+	// OnRoundEnd - called when all stations finished round
+	OnRoundEnd(round int)
+}
+
+// BaseObserver - observer ignoring all events, can be embedded by observers interested in some of them
+type BaseObserver struct{}
+
+func (BaseObserver) OnRoundStart(round int) {}
+
+func (BaseObserver) OnMessageSent(round int, senderId int, receiverId int, data []float64) {}
+
+func (BaseObserver) OnMessageDelivered(round int, senderId int, receiverId int, data []float64) {}
+
+func (BaseObserver) OnMessageDropped(round int, senderId int, receiverId int, data []float64) {}
+
+func (BaseObserver) OnStationStateChanged(round int, station IStation) {}
+
+func (BaseObserver) OnTopologyChanged(round int, changes []EdgeChange) {}
+
+func (BaseObserver) OnRoundEnd(round int) {}
+
+// observers - list of observers registered on manager, notifications are serialized by mutex
+type observers struct {
+	list  []Observer
+	mutex *sync.Mutex
+}
+
+func (o *observers) active() bool {
+	return len(o.list) > 0
+}
+
+func (o *observers) notify(callback func(observer Observer)) {
+	if len(o.list) == 0 {
+		return
+	}
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	for _, observer := range o.list {
+		callback(observer)
+	}
+}
+
+func (o *observers) messageSent(round int, pack *Pack, receiverId int) {
+	if o.active() {
+		o.notify(func(observer Observer) { observer.OnMessageSent(round, pack.SenderId, receiverId, pack.Data) })
+	}
+}
+
+func (o *observers) messageDelivered(round int, pack *Pack, receiverId int) {
+	if o.active() {
+		o.notify(func(observer Observer) { observer.OnMessageDelivered(round, pack.SenderId, receiverId, pack.Data) })
+	}
+}
+
+func (o *observers) messageDropped(round int, pack *Pack, receiverId int) {
+	if o.active() {
+		o.notify(func(observer Observer) { observer.OnMessageDropped(round, pack.SenderId, receiverId, pack.Data) })
+	}
+}
@@ -1,11 +1,23 @@
 package simulation
 
+import "sort"
+
 // Pack - structure for holding message data
 type Pack struct {
+	// Data - payload of message, read only, broadcast pack is shared by all receivers
 	Data        []float64
 	RoundNumber int
+	SenderId    int
+}
+
+// NewPack - creates pack with copy of data, so sender can modify its data after sending,
+// broadcast creates single pack for all neighbours, so receivers must not modify its data
+func NewPack(data []float64, roundNumber int, senderId int) *Pack {
+	return &Pack{Data: append(make([]float64, 0, len(data)), data...), RoundNumber: roundNumber, SenderId: senderId}
 }
 
-func NewPack(data []float64, roundNumber int) *Pack {
-	return &Pack{Data: data, RoundNumber: roundNumber}
+// sortBySender - orders received packs by sender id (packs of the same sender stay in order
+// in which they were sent), so order of delivery does not depend on scheduling of senders
+func sortBySender(packs []*Pack) {
+	sort.SliceStable(packs, func(i, j int) bool { return packs[i].SenderId < packs[j].SenderId })
 }
@@ -0,0 +1,202 @@
+package simulation
+
+import (
+	"app/simulationGraph"
+	"fmt"
+	"math"
+)
+
+// PageRankProtocol - iterative PageRank, stations send rank shares (rank / degree) to neighbours
+// and keep the last share received from every neighbour, a share is resent only if station's rank
+// changed by more than tolerance, station stops when its rank changed by at most tolerance and it received
+// no share for given number of consecutive rounds (or after maximal number of iterations), rank changes
+// travel one hop per round, so too short window stops stations before changes of distant ranks reach them
+type PageRankProtocol struct {
+	damping       float64
+	tolerance     float64
+	maxIterations int
+	quietRounds   int
+
+	// exactRanks - ranks computed centrally by power iteration on original topology
+	exactRanks *exactValues
+}
+
+// pageRankState - state of station computing its PageRank
+type pageRankState struct {
+	// shares - the last share received from every connected neighbour
+	shares         map[int]float64
+	lastSentRank   float64
+	lastSentDegree int
+	// lastUpdateRound - round in which station sent its share for the last time
+	lastUpdateRound int
+	// quietRounds - number of consecutive rounds without rank change and received share
+	quietRounds int
+}
+
+func init() {
+	RegisterProtocol(ProtocolInfo{
+		Name:        "pageRank",
+		Description: "compute PageRank of stations by exchanging rank shares with neighbours",
+		Parameters: []ProtocolParameter{
+			{Name: "d", Type: FloatParameter, Default: "0.85", Description: "damping factor (0..1)"},
+			{Name: "tolerance", Type: FloatParameter, Default: "1e-6", Description: "minimal rank change propagated to neighbours"},
+			{Name: "iterations", Type: IntParameter, Default: "100", Description: "maximal number of iterations"},
+			{Name: "quiet", Type: IntParameter, Default: "10", Description: "number of rounds without rank change and received share after which station stops"},
+		},
+		Factory: func(params ProtocolParams) (Protocol, error) {
+			p := PageRankProtocol{
+				damping:       params.Float("d"),
+				tolerance:     params.Float("tolerance"),
+				maxIterations: params.Int("iterations"),
+				quietRounds:   params.Int("quiet"),
+			}
+			if p.damping <= 0 || p.damping >= 1 {
+				return nil, fmt.Errorf("damping factor should be in range (0,1), got %v", p.damping)
+			}
+			if p.tolerance < 0 || p.maxIterations <= 0 || p.quietRounds <= 0 {
+				return nil, fmt.Errorf("tolerance should be non-negative, iterations and quiet rounds positive")
+			}
+
+			damping := p.damping
+			p.exactRanks = newExactValues(func(g *simulationGraph.GraphWrapper) []float64 {
+				return powerIterationPageRank(g, damping)
+			})
+			return WithState[pageRankState](p), nil
+		},
+	})
+}
+
+func (p PageRankProtocol) GetInitialData(station IStation, state *pageRankState) {
+	n := station.GetGraph().GraphStructure.Order()
+	station.SetCurrentData([]float64{1 / float64(n)})
+	state.shares = map[int]float64{}
+	state.lastSentRank = math.Inf(1)
+	state.lastSentDegree = -1
+}
+
+func (p PageRankProtocol) OnInitialize(station IStation, state *pageRankState) {
+	p.OnDataPropagate(station, state)
+}
+
+func (p PageRankProtocol) OnDataReceive(station IStation, state *pageRankState) {
+	shares := state.shares
+	mq := station.GetMsgQueue()
+	received := mq.Len() > 0
+	for mq.Len() > 0 {
+		msg := mq.Dequeue()
+		shares[msg.SenderId] = msg.Data[0]
+	}
+
+	// shares of neighbours with broken links are no longer received,
+	// shares are summed in order of neighbours, so the sum does not depend on map order
+	neighbours := station.GetNeighbours()
+	sum := 0.
+	current := make(map[int]float64, len(neighbours))
+	for _, w := range neighbours {
+		if share, ok := shares[w]; ok {
+			sum += share
+			current[w] = share
+		}
+	}
+	state.shares = current
+
+	n := station.GetGraph().GraphStructure.Order()
+	rank := (1-p.damping)/float64(n) + p.damping*sum
+	changed := math.Abs(rank-station.GetCurrentData()[0]) > p.tolerance
+	if !received && !changed {
+		state.quietRounds++
+	} else {
+		state.quietRounds = 0
+	}
+	station.SetCurrentData([]float64{rank})
+}
+
+func (p PageRankProtocol) OnDataPropagate(station IStation, state *pageRankState) {
+	rank := station.GetCurrentData()[0]
+	neighbours := station.GetNeighbours()
+	if len(neighbours) == 0 || (math.Abs(rank-state.lastSentRank) <= p.tolerance && len(neighbours) == state.lastSentDegree) {
+		return
+	}
+
+	share := []float64{rank / float64(len(neighbours))}
+	for _, w := range neighbours {
+		station.Send(w, share)
+	}
+	state.lastSentRank = rank
+	state.lastSentDegree = len(neighbours)
+	state.lastUpdateRound = station.GetRoundCounter()
+}
+
+func (p PageRankProtocol) StopCondition(station IStation, state *pageRankState) bool {
+	return station.GetRoundCounter() < p.maxIterations && state.quietRounds < p.quietRounds
+}
+
+func (p PageRankProtocol) OnFinalize(station IStation, state *pageRankState) {
+	station.SetResult(station.GetCurrentData()[0])
+}
+
+func (p PageRankProtocol) CalculateStationExactResult(station IStation, state *pageRankState) float64 {
+	return p.exactRanks.of(station.GetGraph())[station.GetId()]
+}
+
+// CalculateGlobalExactResult - returns the highest exact rank
+func (p PageRankProtocol) CalculateGlobalExactResult(stations *[]IStation) float64 {
+	max := 0.
+	for _, rank := range p.exactRanks.of((*stations)[0].GetGraph()) {
+		max = math.Max(max, rank)
+	}
+
+	return max
+}
+
+func (p PageRankProtocol) CalculateProtocolStats(stations *[]IStation) map[string]interface{} {
+	exact := p.exactRanks.of((*stations)[0].GetGraph())
+	l1Error, maxError := 0., 0.
+	lastUpdateRound := 0
+	for _, station := range *stations {
+		diff := math.Abs(station.GetCurrentData()[0] - exact[station.GetId()])
+		l1Error += diff
+		maxError = math.Max(maxError, diff)
+		if round := StateOf[pageRankState](station).lastUpdateRound; round > lastUpdateRound {
+			lastUpdateRound = round
+		}
+	}
+
+	return map[string]interface{}{
+		"l1_error":          l1Error,
+		"max_error":         maxError,
+		"last_update_round": lastUpdateRound,
+	}
+}
+
+func powerIterationPageRank(g *simulationGraph.GraphWrapper, damping float64) []float64 {
+	n := g.GraphStructure.Order()
+	neighbours := g.GetOriginalNeighbours()
+
+	ranks := make([]float64, n)
+	for i := range ranks {
+		ranks[i] = 1 / float64(n)
+	}
+	for iteration := 0; iteration < 10000; iteration++ {
+		next := make([]float64, n)
+		for v := range next {
+			next[v] = (1 - damping) / float64(n)
+		}
+		for v := 0; v < n; v++ {
+			for _, w := range neighbours[v] {
+				next[w] += damping * ranks[v] / float64(len(neighbours[v]))
+			}
+		}
+
+		change := 0.
+		for v := range ranks {
+			change += math.Abs(next[v] - ranks[v])
+		}
+		ranks = next
+		if change < 1e-12 {
+			break
+		}
+	}
+
+	return ranks
+}
@@ -0,0 +1,44 @@
+package simulation
+
+import (
+	"app/simulationGraph"
+	"math"
+	"testing"
+)
+
+func TestPageRankSumsToOne(t *testing.T) {
+	// star with 5 leaves: center rank c = 0.15/6 + 0.85*5*l, leaf rank l = 0.15/6 + 0.85*c/5
+	center := 0.13125 / 0.2775
+	tests := []struct {
+		name     string
+		g        *simulationGraph.GraphWrapper
+		diameter int
+		// ranks - known ranks of stations (nil if only sum is checked)
+		ranks []float64
+	}{
+		{"clique", simulationGraph.BuildClique(5, "", "0"), 1, []float64{0.2, 0.2, 0.2, 0.2, 0.2}},
+		{"star", simulationGraph.BuildDAryTree(6, 5, "", "0"), 2,
+			[]float64{center, (1 - center) / 5, (1 - center) / 5, (1 - center) / 5, (1 - center) / 5, (1 - center) / 5}},
+		{"grid", simulationGraph.BuildGrid(4, 5, "", "0"), 7, nil},
+		{"path", simulationGraph.BuildPath(7, "", "0"), 6, nil},
+	}
+
+	for _, test := range tests {
+		result := runOnGraph(t, "pageRank", "tolerance=1e-9", test.g, test.diameter)
+		sum, exactSum := 0., 0.
+		for i, station := range result.Stations {
+			sum += station.Result
+			exactSum += station.ExactResult
+			if math.Abs(station.Result-station.ExactResult) > 1e-6 {
+				t.Errorf("%s: station %d computed rank %v, exact rank is %v", test.name, i, station.Result, station.ExactResult)
+			}
+			if test.ranks != nil && math.Abs(station.ExactResult-test.ranks[i]) > 1e-9 {
+				t.Errorf("%s: exact rank of station %d is %v, expected %v", test.name, i, station.ExactResult, test.ranks[i])
+			}
+		}
+
+		if math.Abs(sum-1) > 1e-5 || math.Abs(exactSum-1) > 1e-9 {
+			t.Errorf("%s: ranks sum to %v, exact ranks to %v", test.name, sum, exactSum)
+		}
+	}
+}
@@ -19,3 +19,33 @@ type Protocol interface {
 	// CalculateGlobalExactResult - function used to calculate global result using all stations
 	CalculateGlobalExactResult(stations *[]IStation) float64
 }
+
+// StateChangeReporter - optional interface for protocols which know when station's state changes,
+// engine then does not compute fingerprints of whole station state to detect stable state
+type StateChangeReporter interface {
+	// StateChanged - returns true if station changed its state in current round (called after propagation,
+	// initialization round is always treated as change)
+	StateChanged(station IStation) bool
+}
+
+// ProtocolStatsProvider - optional interface for protocols reporting additional statistics
+type ProtocolStatsProvider interface {
+	// CalculateProtocolStats - returns protocol specific statistics (saved as protocol_stats)
+	CalculateProtocolStats(stations *[]IStation) map[string]interface{}
+}
+
+// GraphValidator - optional interface for protocols with parameters referring to stations,
+// which can be checked only when number of stations is known
+type GraphValidator interface {
+	// ValidateGraph - returns error if protocol cannot run on graph with given number of stations
+	ValidateGraph(nofStations int) error
+}
+
+// ValidateProtocol - checks that protocol can run on graph with given number of stations (see GraphValidator)
+func ValidateProtocol(p Protocol, nofStations int) error {
+	if validator, ok := p.(GraphValidator); ok {
+		return validator.ValidateGraph(nofStations)
+	}
+
+	return nil
+}
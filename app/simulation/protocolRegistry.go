@@ -0,0 +1,176 @@
+package simulation
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ParameterType - type of value accepted by protocol parameter
+type ParameterType string
+
+const (
+	IntParameter    ParameterType = "int"
+	FloatParameter  ParameterType = "float"
+	StringParameter ParameterType = "string"
+)
+
+// ProtocolParameter - description of a single parameter accepted by protocol
+type ProtocolParameter struct {
+	// Name - name used in -protocol-params
+	Name string
+	// Type - type of parameter value
+	Type ParameterType
+	// Default - value used when parameter is not provided
+	Default string
+	// Description - short description of parameter
+	Description string
+}
+
+// ProtocolParams - validated protocol parameters (name -> value)
+type ProtocolParams map[string]string
+
+// ProtocolFactory - function creating protocol from validated parameters
+type ProtocolFactory func(params ProtocolParams) (Protocol, error)
+
+// ProtocolInfo - registry entry describing protocol
+type ProtocolInfo struct {
+	// Name - name used to select protocol (-protocol)
+	Name string
+	// Description - short description of protocol
+	Description string
+	// Parameters - schema of parameters accepted by protocol
+	Parameters []ProtocolParameter
+	// Factory - function creating protocol instance
+	Factory ProtocolFactory
+}
+
+var (
+	registryMutex sync.RWMutex
+	registry      = map[string]ProtocolInfo{}
+)
+
+// RegisterProtocol - registers protocol under given name, usually called from init function
+func RegisterProtocol(info ProtocolInfo) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	if info.Name == "" {
+		panic("simulation: protocol name cannot be empty")
+	}
+	if info.Factory == nil {
+		panic("simulation: protocol factory of " + info.Name + " is nil")
+	}
+	if _, ok := registry[info.Name]; ok {
+		panic("simulation: protocol " + info.Name + " registered twice")
+	}
+
+	registry[info.Name] = info
+}
+
+// GetRegisteredProtocols - returns registered protocols sorted by name
+func GetRegisteredProtocols() []ProtocolInfo {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	protocols := make([]ProtocolInfo, 0, len(registry))
+	for _, info := range registry {
+		protocols = append(protocols, info)
+	}
+	sort.Slice(protocols, func(i, j int) bool {
+		return protocols[i].Name < protocols[j].Name
+	})
+
+	return protocols
+}
+
+// NewProtocol - creates registered protocol with given parameters
+func NewProtocol(name string, params map[string]string) (Protocol, error) {
+	registryMutex.RLock()
+	info, ok := registry[name]
+	registryMutex.RUnlock()
+
+	if !ok {
+		names := make([]string, 0)
+		for _, p := range GetRegisteredProtocols() {
+			names = append(names, p.Name)
+		}
+		return nil, fmt.Errorf("unknown protocol %q (available: %s)", name, strings.Join(names, ", "))
+	}
+
+	validated, err := info.validateParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return info.Factory(validated)
+}
+
+// ParseProtocolParams - parses parameters given in form key=value,key=value
+func ParseProtocolParams(str string) (map[string]string, error) {
+	params := map[string]string{}
+	if strings.TrimSpace(str) == "" {
+		return params, nil
+	}
+
+	for _, pair := range strings.Split(str, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("invalid protocol parameter %q, expected key=value", pair)
+		}
+		params[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+
+	return params, nil
+}
+
+func (info ProtocolInfo) validateParams(params map[string]string) (ProtocolParams, error) {
+	validated := ProtocolParams{}
+	schema := map[string]ProtocolParameter{}
+	for _, p := range info.Parameters {
+		schema[p.Name] = p
+		validated[p.Name] = p.Default
+	}
+
+	for key, value := range params {
+		if _, ok := schema[key]; !ok {
+			return nil, fmt.Errorf("protocol %s does not accept parameter %q", info.Name, key)
+		}
+		validated[key] = value
+	}
+
+	for _, p := range info.Parameters {
+		value := validated[p.Name]
+		var err error
+		switch p.Type {
+		case IntParameter:
+			_, err = strconv.Atoi(value)
+		case FloatParameter:
+			_, err = strconv.ParseFloat(value, 64)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parameter %s of protocol %s should be %s, got %q", p.Name, info.Name, p.Type, value)
+		}
+	}
+
+	return validated, nil
+}
+
+// Int - returns value of int parameter
+func (params ProtocolParams) Int(name string) int {
+	value, _ := strconv.Atoi(params[name])
+	return value
+}
+
+// Float - returns value of float parameter
+func (params ProtocolParams) Float(name string) float64 {
+	value, _ := strconv.ParseFloat(params[name], 64)
+	return value
+}
+
+// String - returns value of string parameter
+func (params ProtocolParams) String(name string) string {
+	return params[name]
+}
@@ -0,0 +1,130 @@
+package simulation
+
+import (
+	"fmt"
+)
+
+// QuantileProtocol - quantile estimation protocol based on mergeable KLL sketches,
+// every station floods sketches of other stations it has not seen before, so each sketch is merged exactly once
+type QuantileProtocol struct {
+	q              float64
+	k              int
+	nofObservables int
+	mean           float64
+	shift          float64
+	stddev         float64
+}
+
+// quantileState - state of station estimating quantile
+type quantileState struct {
+	sketch       *kllSketch
+	seenStations map[int]struct{}
+	// newSketches - (stationId, encoded sketch) for each sketch learned in current round
+	newSketches []float64
+}
+
+func init() {
+	RegisterProtocol(ProtocolInfo{
+		Name:        "quantile",
+		Description: "estimate quantile of observed values using KLL sketches",
+		Parameters: []ProtocolParameter{
+			{Name: "q", Type: FloatParameter, Default: "0.5", Description: "estimated quantile (0..1)"},
+			{Name: "k", Type: IntParameter, Default: "200", Description: "KLL sketch accuracy parameter"},
+			{Name: "observations", Type: IntParameter, Default: "20", Description: "number of values observed by each station"},
+			{Name: "mean", Type: FloatParameter, Default: "20", Description: "mean of observed values (normal distribution)"},
+			{Name: "shift", Type: FloatParameter, Default: "0", Description: "mean of values observed by station i is mean+shift*i"},
+			{Name: "stddev", Type: FloatParameter, Default: "5", Description: "standard deviation of observed values"},
+		},
+		Factory: func(params ProtocolParams) (Protocol, error) {
+			p := QuantileProtocol{
+				q:              params.Float("q"),
+				k:              params.Int("k"),
+				nofObservables: params.Int("observations"),
+				mean:           params.Float("mean"),
+				shift:          params.Float("shift"),
+				stddev:         params.Float("stddev"),
+			}
+			if p.q < 0 || p.q > 1 {
+				return nil, fmt.Errorf("quantile should be in range [0,1], got %v", p.q)
+			}
+			if p.k < 8 {
+				return nil, fmt.Errorf("k should be at least 8, got %d", p.k)
+			}
+			if p.nofObservables <= 0 {
+				return nil, fmt.Errorf("number of observations should be positive, got %d", p.nofObservables)
+			}
+
+			return WithState[quantileState](p), nil
+		},
+	})
+}
+
+func (p QuantileProtocol) GetInitialData(station IStation, state *quantileState) {
+	state.sketch = newKllSketch(p.k)
+	for i := 0; i < p.nofObservables; i++ {
+		value := p.mean + p.shift*float64(station.GetId()) + p.stddev*station.GetRandom().NormFloat64()
+		station.ObserveValue([]float64{value})
+		state.sketch.Update(value, station.GetRandom())
+	}
+
+	state.seenStations = map[int]struct{}{station.GetId(): {}}
+	// message data: (stationId, encoded sketch) for each newly learned sketch
+	station.SetCurrentData(state.sketch.encode([]float64{float64(station.GetId())}))
+}
+
+func (QuantileProtocol) OnInitialize(station IStation, state *quantileState) {
+	station.Broadcast()
+}
+
+func (p QuantileProtocol) OnDataReceive(station IStation, state *quantileState) {
+	state.newSketches = make([]float64, 0)
+	mq := station.GetMsgQueue()
+	for mq.Len() > 0 {
+		data := mq.Dequeue().Data
+		for len(data) > 0 {
+			id := int(data[0])
+			var received *kllSketch
+			received, data = decodeKllSketch(data[1:], p.k)
+			if _, ok := state.seenStations[id]; ok {
+				continue
+			}
+
+			state.seenStations[id] = struct{}{}
+			state.newSketches = received.encode(append(state.newSketches, float64(id)))
+			state.sketch.Merge(received, station.GetRandom())
+		}
+	}
+
+	if len(state.newSketches) > 0 {
+		station.SetCurrentData(state.newSketches)
+	}
+}
+
+func (QuantileProtocol) OnDataPropagate(station IStation, state *quantileState) {
+	if len(state.newSketches) > 0 {
+		station.SynchronizedBroadcast()
+	}
+}
+
+func (QuantileProtocol) StopCondition(station IStation, state *quantileState) bool {
+	return station.GetRoundCounter() < station.GetGraph().GetDiameter()
+}
+
+func (p QuantileProtocol) OnFinalize(station IStation, state *quantileState) {
+	station.SetResult(state.sketch.Quantile(p.q))
+}
+
+func (QuantileProtocol) CalculateStationExactResult(station IStation, state *quantileState) float64 {
+	return -1
+}
+
+func (p QuantileProtocol) CalculateGlobalExactResult(stations *[]IStation) float64 {
+	values := make([]float64, 0)
+	for _, station := range *stations {
+		for _, v := range station.GetObservedValues() {
+			values = append(values, v[0])
+		}
+	}
+
+	return exactQuantile(values, p.q)
+}
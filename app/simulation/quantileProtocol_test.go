@@ -0,0 +1,61 @@
+package simulation
+
+import (
+	"app/simulationGraph"
+	"testing"
+)
+
+// graphRunConfig - returns configuration of run on reliable graph with sequential engine
+func graphRunConfig(protocol, params string, g *simulationGraph.GraphWrapper, diameter int) RunConfig {
+	return RunConfig{
+		Protocol:       protocol,
+		ProtocolParams: params,
+		Seed:           3,
+		Engine:         SequentialEngine,
+		NofWorkers:     1,
+		Diameter:       diameter,
+		Graph:          simulationGraph.NewJsonGraphStructure(g),
+	}
+}
+
+// runOnGraph - runs protocol on reliable graph with sequential engine, returns statistics of run
+func runOnGraph(t *testing.T, protocol, params string, g *simulationGraph.GraphWrapper, diameter int) JsonStatsStructure {
+	t.Helper()
+	return runConfig(t, graphRunConfig(protocol, params, g, diameter))
+}
+
+// runConfig - runs simulation with given configuration, returns statistics of run
+func runConfig(t *testing.T, conf RunConfig) JsonStatsStructure {
+	t.Helper()
+	p, manager, err := conf.build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return manager.RunSimulation(p)
+}
+
+func TestQuantileOfStationDependentReadings(t *testing.T) {
+	// station i observes values around 10*i, sketches are large enough to keep every reading,
+	// so every station computes exact quantile of all readings if each of them is counted once
+	tests := []struct {
+		name     string
+		g        *simulationGraph.GraphWrapper
+		diameter int
+	}{
+		{"path", simulationGraph.BuildPath(7, "", "0"), 6},
+		{"star", simulationGraph.BuildDAryTree(7, 6, "", "0"), 2},
+	}
+
+	for _, test := range tests {
+		for _, q := range []string{"0.2", "0.5", "0.9"} {
+			result := runOnGraph(t, "quantile", "q="+q+",k=400,observations=10,mean=0,shift=10,stddev=1", test.g, test.diameter)
+			for _, station := range result.Stations {
+				if station.Result != result.Result {
+					t.Errorf("%s, q=%s: station %d estimated %v, exact quantile is %v",
+						test.name, q, station.GetId(), station.Result, result.Result)
+				}
+			}
+		}
+	}
+}
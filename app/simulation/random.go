@@ -0,0 +1,71 @@
+package simulation
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// randomState - state of random generator saved in checkpoints: seed and number of values drawn since seeding
+type randomState struct {
+	Seed  int64
+	Draws uint64
+}
+
+// countingSource - thread-safe source of random values which counts values drawn from it,
+// so its state can be saved and restored by reseeding and skipping the same number of values
+type countingSource struct {
+	mutex *sync.Mutex
+	src   rand.Source64
+	seed  int64
+	draws uint64
+}
+
+func newCountingSource(seed int64) *countingSource {
+	return &countingSource{mutex: &sync.Mutex{}, src: rand.NewSource(seed).(rand.Source64), seed: seed}
+}
+
+func (s *countingSource) Int63() int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.draws++
+	return s.src.Int63()
+}
+
+func (s *countingSource) Uint64() uint64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.draws++
+	return s.src.Uint64()
+}
+
+func (s *countingSource) Seed(seed int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.src.Seed(seed)
+	s.seed = seed
+	s.draws = 0
+}
+
+func (s *countingSource) state() randomState {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return randomState{Seed: s.seed, Draws: s.draws}
+}
+
+// restore - brings source to given state
+func (s *countingSource) restore(state randomState) {
+	s.Seed(state.Seed)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for ; s.draws < state.Draws; s.draws++ {
+		s.src.Uint64()
+	}
+}
+
+// stationSeed - derives seed of station's random generator from seed of simulation (splitmix64 mixing)
+func stationSeed(seed int64, id int) int64 {
+	x := uint64(seed) + uint64(id+1)*0x9E3779B97F4A7C15
+	x = (x ^ x>>30) * 0xBF58476D1CE4E5B9
+	x = (x ^ x>>27) * 0x94D049BB133111EB
+	return int64(x ^ x>>31)
+}
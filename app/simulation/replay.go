@@ -0,0 +1,227 @@
+package simulation
+
+import (
+	"app/simulationGraph"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// ReplayReport - result of trace replay
+type ReplayReport struct {
+	// NofRounds - number of rounds in trace
+	NofRounds int `json:"nof_rounds"`
+	// VerifiedRounds - number of rounds in which replayed events matched recorded ones
+	VerifiedRounds int  `json:"verified_rounds"`
+	Diverged       bool `json:"diverged"`
+	// DivergenceRound, Divergence - first round in which events differ and description of difference
+	DivergenceRound int    `json:"divergence_round"`
+	Divergence      string `json:"divergence"`
+}
+
+// recordedTrace - events of trace file grouped by rounds
+type recordedTrace struct {
+	header    RunConfig
+	nofRounds int
+	// events - canonical descriptions of events in every round
+	events map[int][]string
+	// changes - topology changes made before every round
+	changes map[int][]EdgeChange
+	// deliveries - order of senders of messages delivered to station in a round
+	deliveries map[int]map[int][]int
+}
+
+func readTrace(path string) (*recordedTrace, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	var first traceEvent
+	if err := decoder.Decode(&first); err != nil || first.Event != traceHeaderEvent || first.Header == nil {
+		return nil, fmt.Errorf("trace %s does not start with header", path)
+	}
+
+	trace := &recordedTrace{
+		header:     *first.Header,
+		events:     map[int][]string{},
+		changes:    map[int][]EdgeChange{},
+		deliveries: map[int]map[int][]int{},
+	}
+	for decoder.More() {
+		var e traceEvent
+		if err := decoder.Decode(&e); err != nil {
+			return nil, fmt.Errorf("invalid trace %s: %v", path, err)
+		}
+
+		switch e.Event {
+		case traceRoundEndEvent:
+			trace.nofRounds++
+			continue
+		case traceTopologyEvent:
+			trace.changes[e.Round] = append(trace.changes[e.Round], e.Changes...)
+		case traceDeliverEvent:
+			if trace.deliveries[e.Round] == nil {
+				trace.deliveries[e.Round] = map[int][]int{}
+			}
+			trace.deliveries[e.Round][*e.Receiver] = append(trace.deliveries[e.Round][*e.Receiver], *e.Sender)
+		}
+		trace.events[e.Round] = append(trace.events[e.Round], e.describe())
+	}
+
+	return trace, nil
+}
+
+// describe - returns canonical description of event used to compare recorded and replayed events
+func (e traceEvent) describe() string {
+	switch e.Event {
+	case traceStateEvent:
+		return fmt.Sprintf("%s station=%d hash=%016x", e.Event, *e.Station, e.Hash)
+	case traceTopologyEvent:
+		return fmt.Sprintf("%s %v", e.Event, e.Changes)
+	}
+
+	return fmt.Sprintf("%s %d->%d hash=%016x", e.Event, *e.Sender, *e.Receiver, e.Hash)
+}
+
+// replayUpdater - edge updater applying topology changes recorded in trace
+type replayUpdater struct {
+	g       *simulationGraph.GraphWrapper
+	changes map[int][]EdgeChange
+	round   int
+}
+
+func (u *replayUpdater) UpdateEdges() []EdgeChange {
+	// edges are updated once before every round starting from round 1
+	u.round++
+	for _, c := range u.changes[u.round] {
+		if c.Removed {
+			u.g.GraphStructure.DeleteBoth(c.V, c.W)
+		} else {
+			u.g.GraphStructure.AddBoth(c.V, c.W)
+		}
+	}
+
+	return u.changes[u.round]
+}
+
+// replayVerifier - observer comparing replayed events with recorded ones round by round
+type replayVerifier struct {
+	BaseObserver
+	trace  *recordedTrace
+	events map[int][]string
+	report ReplayReport
+	rounds int
+}
+
+func (v *replayVerifier) add(round int, e traceEvent) {
+	v.events[round] = append(v.events[round], e.describe())
+}
+
+func (v *replayVerifier) OnMessageSent(round int, senderId int, receiverId int, data []float64) {
+	v.add(round, traceEvent{Event: traceSendEvent, Sender: &senderId, Receiver: &receiverId, Hash: payloadHash(data)})
+}
+
+func (v *replayVerifier) OnMessageDelivered(round int, senderId int, receiverId int, data []float64) {
+	v.add(round, traceEvent{Event: traceDeliverEvent, Sender: &senderId, Receiver: &receiverId, Hash: payloadHash(data)})
+}
+
+func (v *replayVerifier) OnMessageDropped(round int, senderId int, receiverId int, data []float64) {
+	v.add(round, traceEvent{Event: traceDropEvent, Sender: &senderId, Receiver: &receiverId, Hash: payloadHash(data)})
+}
+
+func (v *replayVerifier) OnStationStateChanged(round int, station IStation) {
+	id := station.GetId()
+	state := station.GetStation()
+	v.add(round, traceEvent{Event: traceStateEvent, Station: &id, Hash: stateFingerprint(&state)})
+}
+
+func (v *replayVerifier) OnTopologyChanged(round int, changes []EdgeChange) {
+	v.add(round, traceEvent{Event: traceTopologyEvent, Changes: changes})
+}
+
+func (v *replayVerifier) OnRoundEnd(round int) {
+	v.rounds++
+	expected, actual := v.trace.events[round], v.events[round]
+	delete(v.events, round)
+	if v.report.Diverged {
+		return
+	}
+
+	sort.Strings(expected)
+	sort.Strings(actual)
+	for i := 0; i < len(expected) || i < len(actual); i++ {
+		if i < len(expected) && i < len(actual) && expected[i] == actual[i] {
+			continue
+		}
+
+		e, a := "nothing", "nothing"
+		if i < len(expected) {
+			e = expected[i]
+		}
+		if i < len(actual) {
+			a = actual[i]
+		}
+		v.diverge(round, fmt.Sprintf("expected %s, got %s", e, a))
+		return
+	}
+	v.report.VerifiedRounds++
+}
+
+func (v *replayVerifier) diverge(round int, description string) {
+	v.report.Diverged = true
+	v.report.DivergenceRound = round
+	v.report.Divergence = description
+}
+
+// orderInbox - orders messages received by station as they were delivered in recorded run
+func (v *replayVerifier) orderInbox(round int, receiverId int, inbox []*Pack) {
+	position := map[int]int{}
+	for i, sender := range v.trace.deliveries[round][receiverId] {
+		if _, ok := position[sender]; !ok {
+			position[sender] = i
+		}
+	}
+	rank := func(p *Pack) int {
+		if i, ok := position[p.SenderId]; ok {
+			return i
+		}
+		return len(position)
+	}
+	sort.SliceStable(inbox, func(i, j int) bool { return rank(inbox[i]) < rank(inbox[j]) })
+}
+
+// ReplayTrace - re-executes protocol recorded in trace file with the same seed, topology changes
+// and message ordering using sequential engine, and verifies events round by round. Stations draw random
+// values from their own generators and receive messages in sender order, so runs recorded with any engine
+// and number of workers are reproduced.
+func ReplayTrace(path string) (JsonStatsStructure, ReplayReport, error) {
+	trace, err := readTrace(path)
+	if err != nil {
+		return JsonStatsStructure{}, ReplayReport{}, err
+	}
+
+	p, manager, err := trace.header.build()
+	if err != nil {
+		return JsonStatsStructure{}, ReplayReport{}, err
+	}
+	if err := manager.SetEngine(SequentialEngine, 1); err != nil {
+		return JsonStatsStructure{}, ReplayReport{}, err
+	}
+
+	verifier := &replayVerifier{trace: trace, events: map[int][]string{}}
+	manager.SetEdgeUpdater(&replayUpdater{g: manager.graph, changes: trace.changes})
+	manager.orderInbox = verifier.orderInbox
+	manager.AddObserver(verifier)
+
+	result := manager.RunSimulation(p)
+	verifier.report.NofRounds = trace.nofRounds
+	if !verifier.report.Diverged && verifier.rounds != trace.nofRounds {
+		verifier.diverge(verifier.rounds-1, fmt.Sprintf("recorded %d rounds, replayed %d", trace.nofRounds, verifier.rounds))
+	}
+
+	return result, verifier.report, nil
+}
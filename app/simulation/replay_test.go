@@ -0,0 +1,71 @@
+package simulation
+
+import (
+	"app/simulationGraph"
+	"path/filepath"
+	"testing"
+)
+
+// testRunConfig - returns configuration of simulation on 5x5 grid
+func testRunConfig(protocol, params, reliabilityModel, engine string, nofWorkers int) RunConfig {
+	g := simulationGraph.BuildGrid(5, 5, reliabilityModel, "0.8")
+	return RunConfig{
+		Protocol:         protocol,
+		ProtocolParams:   params,
+		Seed:             7,
+		Engine:           engine,
+		NofWorkers:       nofWorkers,
+		ReliabilityModel: reliabilityModel,
+		Diameter:         8,
+		Payloads:         true,
+		Graph:            simulationGraph.NewJsonGraphStructure(g),
+	}
+}
+
+func TestReplayReproducesRecordedRun(t *testing.T) {
+	tests := []struct {
+		protocol         string
+		params           string
+		reliabilityModel string
+		engine           string
+		nofWorkers       int
+	}{
+		{"hll", "", "", GoroutineEngine, 1},
+		{"rumor", "mode=pushPull", "edge-remover", GoroutineEngine, 1},
+		{"kMeans", "iterations=3", "gilbert-elliott", GoroutineEngine, 1},
+		{"coloring", "", "edge-remover-adder", SequentialEngine, 4},
+		{"quantile", "", "", SequentialEngine, 1},
+	}
+
+	for _, test := range tests {
+		conf := testRunConfig(test.protocol, test.params, test.reliabilityModel, test.engine, test.nofWorkers)
+		p, manager, err := conf.build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(t.TempDir(), "trace.jsonl")
+		recorder, err := NewTraceRecorder(path, conf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		manager.AddObserver(recorder)
+		recorded := manager.RunSimulation(p)
+		if err := recorder.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		replayed, report, err := ReplayTrace(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Diverged {
+			t.Errorf("%s recorded with %s engine: replay diverged in round %d: %s",
+				test.protocol, test.engine, report.DivergenceRound, report.Divergence)
+		}
+		// replay applies recorded topology changes instead of running reliability model
+		recorded.ReliabilityStats = replayed.ReliabilityStats
+		if diff := DiffStats(recorded, replayed); diff != "" {
+			t.Errorf("%s recorded with %s engine: replayed stats differ: %s", test.protocol, test.engine, diff)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package simulation
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// RumorProtocol - random phone call rumor spreading started from seed stations.
+// Each round participating stations call random neighbour: in "push" informed stations call and send the rumor,
+// in "pull" uninformed stations call and informed callees answer with the rumor, in "pushPull" everybody calls.
+// In SIR model informed station stops spreading (is removed) after k useless contacts, i.e. after pushing
+// the rumor to stations which already knew it (callee answers with its state, so caller learns it a round later).
+// Message is a pair (call, response), -1 means absent, otherwise informed flag of its sender.
+type RumorProtocol struct {
+	push      bool
+	pull      bool
+	sir       bool
+	k         int
+	seeds     map[int]struct{}
+	nofRounds int // 0 - chosen automatically based on diameter and number of stations
+}
+
+// rumorState - state of station in rumor spreading
+type rumorState struct {
+	informedRound   int // -1 if station does not know the rumor
+	removed         bool
+	uselessContacts int
+	// responses - responses to calls received in current round (by caller)
+	responses map[int]float64
+	// callsInformed - informed flags of two most recent calls, response to the older one arrives in next receive phase
+	callsInformed [2]bool
+}
+
+func init() {
+	RegisterProtocol(ProtocolInfo{
+		Name:        "rumor",
+		Description: "spread rumor from seed stations using push, pull or push-pull random phone calls",
+		Parameters: []ProtocolParameter{
+			{Name: "mode", Type: StringParameter, Default: "push", Description: "push|pull|pushPull"},
+			{Name: "model", Type: StringParameter, Default: "si", Description: "si|sir (stop after k useless contacts)"},
+			{Name: "k", Type: IntParameter, Default: "2", Description: "useless contacts after which station is removed (sir)"},
+			{Name: "seeds", Type: StringParameter, Default: "0", Description: "ids of seed stations separated by ';'"},
+			{Name: "rounds", Type: IntParameter, Default: "0", Description: "number of rounds (0 - 4*(diameter+log2(n)))"},
+		},
+		Factory: newRumorProtocol,
+	})
+}
+
+func newRumorProtocol(params ProtocolParams) (Protocol, error) {
+	p := RumorProtocol{k: params.Int("k"), seeds: map[int]struct{}{}, nofRounds: params.Int("rounds")}
+
+	switch params.String("mode") {
+	case "push":
+		p.push = true
+	case "pull":
+		p.pull = true
+	case "pushPull":
+		p.push, p.pull = true, true
+	default:
+		return nil, fmt.Errorf("unknown rumor mode %q (push|pull|pushPull)", params.String("mode"))
+	}
+
+	switch params.String("model") {
+	case "si":
+	case "sir":
+		p.sir = true
+	default:
+		return nil, fmt.Errorf("unknown rumor model %q (si|sir)", params.String("model"))
+	}
+	if p.k <= 0 {
+		return nil, fmt.Errorf("k should be positive, got %d", p.k)
+	}
+
+	for _, s := range strings.Split(params.String("seeds"), ";") {
+		id, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil || id < 0 {
+			return nil, fmt.Errorf("invalid seed station %q", s)
+		}
+		p.seeds[id] = struct{}{}
+	}
+
+	return WithState[rumorState](p), nil
+}
+
+// ValidateGraph - checks that seed stations exist
+func (p RumorProtocol) ValidateGraph(nofStations int) error {
+	for id := range p.seeds {
+		if id >= nofStations {
+			return fmt.Errorf("seed station %d does not exist in graph of %d stations", id, nofStations)
+		}
+	}
+
+	return nil
+}
+
+func (p RumorProtocol) GetInitialData(station IStation, state *rumorState) {
+	state.informedRound = -1
+	if _, ok := p.seeds[station.GetId()]; ok {
+		state.informedRound = 0
+	}
+	state.responses = map[int]float64{}
+	station.SetCurrentData([]float64{rumorFlag(state.informedRound >= 0)})
+}
+
+func rumorFlag(informed bool) float64 {
+	if informed {
+		return 1
+	}
+	return 0
+}
+
+func (p RumorProtocol) OnInitialize(station IStation, state *rumorState) {
+	p.OnDataPropagate(station, state)
+}
+
+func (p RumorProtocol) OnDataReceive(station IStation, state *rumorState) {
+	wasInformed := state.informedRound >= 0
+	informed := wasInformed
+	responses := map[int]float64{}
+
+	mq := station.GetMsgQueue()
+	for mq.Len() > 0 {
+		msg := mq.Dequeue()
+		call, response := msg.Data[0], msg.Data[1]
+
+		if response >= 0 {
+			// callee knew the rumor - pull succeeded or push was useless
+			if response == 1 && !informed {
+				informed = true
+			} else if response == 1 && state.callsInformed[0] {
+				state.uselessContacts++
+			}
+		}
+
+		if call >= 0 {
+			if call == 1 && p.push && !informed {
+				informed = true
+			}
+			if p.pull && wasInformed && !state.removed && call == 0 {
+				responses[msg.SenderId] = 1
+			} else if p.sir && p.push && call == 1 {
+				responses[msg.SenderId] = rumorFlag(wasInformed)
+			}
+		}
+	}
+
+	if informed && !wasInformed {
+		// round 0 is initialization, messages received in i-th iteration belong to round i+1
+		state.informedRound = station.GetRoundCounter() + 1
+		station.SetCurrentData([]float64{1})
+	}
+	if p.sir && state.uselessContacts >= p.k {
+		state.removed = true
+	}
+	state.responses = responses
+}
+
+func (p RumorProtocol) OnDataPropagate(station IStation, state *rumorState) {
+	informed := state.informedRound >= 0
+
+	out := map[int][]float64{}
+	for w, response := range state.responses {
+		out[w] = []float64{-1, response}
+	}
+
+	calling := !state.removed && ((p.push && informed) || (p.pull && !informed) || (p.push && p.pull))
+	if neighbours := station.GetNeighbours(); calling && len(neighbours) > 0 {
+		w := neighbours[station.GetRandom().Intn(len(neighbours))]
+		if _, ok := out[w]; !ok {
+			out[w] = []float64{-1, -1}
+		}
+		out[w][0] = rumorFlag(informed)
+	}
+	state.callsInformed = [2]bool{state.callsInformed[1], calling && informed}
+
+	for w, msg := range out {
+		station.Send(w, msg)
+	}
+}
+
+func (p RumorProtocol) StopCondition(station IStation, state *rumorState) bool {
+	return station.GetRoundCounter() < p.rounds(station)
+}
+
+func (p RumorProtocol) rounds(station IStation) int {
+	if p.nofRounds > 0 {
+		return p.nofRounds
+	}
+	g := station.GetGraph()
+	n := g.GraphStructure.Order()
+	return 4 * (g.GetDiameter() + int(math.Ceil(math.Log2(float64(n)+1))))
+}
+
+func (RumorProtocol) OnFinalize(station IStation, state *rumorState) {
+	station.SetResult(station.GetCurrentData()[0])
+}
+
+func (RumorProtocol) CalculateStationExactResult(station IStation, state *rumorState) float64 {
+	return 1
+}
+
+// CalculateGlobalExactResult - returns number of stations which should be informed
+func (RumorProtocol) CalculateGlobalExactResult(stations *[]IStation) float64 {
+	return float64(len(*stations))
+}
+
+func (p RumorProtocol) CalculateProtocolStats(stations *[]IStation) map[string]interface{} {
+	nofRounds := p.rounds((*stations)[0])
+	infectedPerRound := make([]int, nofRounds+1)
+	residue, removed, fullCoverage := 0, 0, 0
+	for _, station := range *stations {
+		state := StateOf[rumorState](station)
+		informedRound := state.informedRound
+		if informedRound < 0 {
+			residue++
+		} else {
+			for r := informedRound; r <= nofRounds; r++ {
+				infectedPerRound[r]++
+			}
+			if informedRound > fullCoverage {
+				fullCoverage = informedRound
+			}
+		}
+		if state.removed {
+			removed++
+		}
+	}
+	if residue > 0 {
+		fullCoverage = -1
+	}
+
+	return map[string]interface{}{
+		"infected_per_round":      infectedPerRound,
+		"rounds_to_full_coverage": fullCoverage,
+		"residue":                 residue,
+		"residue_fraction":        float64(residue) / float64(len(*stations)),
+		"removed":                 removed,
+	}
+}
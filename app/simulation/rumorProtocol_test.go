@@ -0,0 +1,57 @@
+package simulation
+
+import (
+	"app/simulationGraph"
+	"testing"
+)
+
+func TestRumorRejectsMissingSeeds(t *testing.T) {
+	g := simulationGraph.BuildPath(5, "", "0")
+	for _, seeds := range []string{"5", "0;7"} {
+		conf := graphRunConfig("rumor", "seeds="+seeds, g, 4)
+		if _, _, err := conf.build(); err == nil {
+			t.Errorf("seeds %s: expected error for graph of 5 stations", seeds)
+		}
+	}
+
+	conf := graphRunConfig("rumor", "seeds=0;4", g, 4)
+	if _, _, err := conf.build(); err != nil {
+		t.Errorf("seeds 0;4: %v", err)
+	}
+}
+
+func TestRumorInformsAllStations(t *testing.T) {
+	tests := []struct {
+		name     string
+		g        *simulationGraph.GraphWrapper
+		diameter int
+		seeds    string
+		nofSeeds int
+		// eccentricity - distance of the farthest station from seeds, lower bound of rounds to full coverage
+		eccentricity int
+	}{
+		{"path", simulationGraph.BuildPath(8, "", "0"), 7, "0", 1, 7},
+		{"grid", simulationGraph.BuildGrid(5, 5, "", "0"), 8, "0;24", 2, 4},
+		{"clique", simulationGraph.BuildClique(16, "", "0"), 1, "3", 1, 1},
+	}
+
+	for _, test := range tests {
+		for _, mode := range []string{"push", "pull", "pushPull"} {
+			result := runOnGraph(t, "rumor", "mode="+mode+",seeds="+test.seeds, test.g, test.diameter)
+			for _, station := range result.Stations {
+				if station.Result != 1 {
+					t.Errorf("%s, %s: station %d is not informed", test.name, mode, station.GetId())
+				}
+			}
+
+			stats := result.ProtocolStats
+			if infected := stats["infected_per_round"].([]int); infected[0] != test.nofSeeds {
+				t.Errorf("%s, %s: %d stations informed in round 0, expected %d", test.name, mode, infected[0], test.nofSeeds)
+			}
+			if rounds := stats["rounds_to_full_coverage"].(int); rounds < test.eccentricity {
+				t.Errorf("%s, %s: full coverage after %d rounds, farthest station is %d hops away",
+					test.name, mode, rounds, test.eccentricity)
+			}
+		}
+	}
+}
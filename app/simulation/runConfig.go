@@ -0,0 +1,67 @@
+package simulation
+
+import (
+	"app/simulationGraph"
+	"fmt"
+)
+
+// RunConfig - configuration of simulation run, contains everything needed to recreate the simulation
+// (stored in trace header and checkpoints)
+type RunConfig struct {
+	Protocol         string                              `json:"protocol"`
+	ProtocolParams   string                              `json:"protocol_params"`
+	Seed             int64                               `json:"seed"`
+	Engine           string                              `json:"engine"`
+	NofWorkers       int                                 `json:"workers"`
+	ReliabilityModel string                              `json:"reliability_model"`
+	Probability      string                              `json:"probability"`
+	ReliabilityRoot  int                                 `json:"reliability_root"`
+	Scenario         *Scenario                           `json:"scenario,omitempty"`
+	Termination      string                              `json:"termination"`
+	MaxRounds        int                                 `json:"max_rounds"`
+	Diameter         int                                 `json:"diameter"`
+	Payloads         bool                                `json:"payloads"`
+	Graph            *simulationGraph.JsonGraphStructure `json:"graph"`
+}
+
+// build - recreates protocol and manager of simulation, random generators are seeded with configured seed
+func (conf RunConfig) build() (Protocol, *Manager, error) {
+	if conf.Graph == nil {
+		return nil, nil, fmt.Errorf("configuration does not contain graph")
+	}
+	params, err := ParseProtocolParams(conf.ProtocolParams)
+	if err != nil {
+		return nil, nil, err
+	}
+	termination, err := ParseTermination(conf.Termination, conf.MaxRounds)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p, err := NewProtocol(conf.Protocol, params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	g := simulationGraph.BuildGraphFromConfig(*conf.Graph)
+	if err := ValidateProtocol(p, g.GraphStructure.Order()); err != nil {
+		return nil, nil, err
+	}
+	g.SetDiameter(conf.Diameter)
+	manager := NewManager(conf.ReliabilityModel, g)
+	manager.SetSeed(conf.Seed)
+	manager.SetTermination(termination)
+	if err := manager.SetReliabilityExpression(conf.Probability, conf.ReliabilityRoot); err != nil {
+		return nil, nil, err
+	}
+	if conf.Scenario != nil {
+		if err := manager.SetScenario(conf.Scenario); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := manager.SetEngine(conf.Engine, conf.NofWorkers); err != nil {
+		return nil, nil, err
+	}
+
+	return p, manager, nil
+}
@@ -0,0 +1,251 @@
+package simulation
+
+import (
+	"app/simulationGraph"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+const (
+	// ScenarioRemove - removes given edges
+	ScenarioRemove = "remove"
+	// ScenarioAdd - adds given edges (also edges which are not in original topology)
+	ScenarioAdd = "add"
+	// ScenarioIsolate - removes all edges of given station
+	ScenarioIsolate = "isolate"
+	// ScenarioPartition - removes edges between given groups of stations, stations outside groups form one more group
+	ScenarioPartition = "partition"
+	// ScenarioRestore - restores original topology
+	ScenarioRestore = "restore"
+)
+
+// Scenario - timed topology events read from JSON file
+type Scenario struct {
+	Events []ScenarioEvent `json:"events"`
+}
+
+// ScenarioEvent - topology event applied before given round starts
+type ScenarioEvent struct {
+	Round   int      `json:"round"`
+	Action  string   `json:"action"`
+	Edges   [][2]int `json:"edges,omitempty"`
+	Station int      `json:"station,omitempty"`
+	Groups  [][]int  `json:"groups,omitempty"`
+}
+
+// ReadScenario - reads scenario from JSON file
+func ReadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	scenario := &Scenario{}
+	if err := json.Unmarshal(data, scenario); err != nil {
+		return nil, fmt.Errorf("invalid scenario %s: %v", path, err)
+	}
+	return scenario, nil
+}
+
+// validate - checks that events refer to existing stations
+func (s *Scenario) validate(nofStations int) error {
+	station := func(v int) error {
+		if v < 0 || v >= nofStations {
+			return fmt.Errorf("station %d does not exist", v)
+		}
+		return nil
+	}
+
+	for i, e := range s.Events {
+		var err error
+		if e.Round < 1 {
+			err = fmt.Errorf("round should be positive, got %d", e.Round)
+		}
+		switch e.Action {
+		case ScenarioRemove, ScenarioAdd:
+			for _, edge := range e.Edges {
+				if err == nil {
+					err = station(edge[0])
+				}
+				if err == nil {
+					err = station(edge[1])
+				}
+			}
+		case ScenarioIsolate:
+			if err == nil {
+				err = station(e.Station)
+			}
+		case ScenarioPartition:
+			for _, group := range e.Groups {
+				for _, v := range group {
+					if err == nil {
+						err = station(v)
+					}
+				}
+			}
+		case ScenarioRestore:
+		default:
+			err = fmt.Errorf("unknown action %q (%s|%s|%s|%s|%s)", e.Action,
+				ScenarioRemove, ScenarioAdd, ScenarioIsolate, ScenarioPartition, ScenarioRestore)
+		}
+		if err != nil {
+			return fmt.Errorf("scenario event %d: %v", i, err)
+		}
+	}
+
+	return nil
+}
+
+// scenarioUpdater - edge updater applying scenario events, edges removed by scenario stay blocked
+// (removed again if reliability model adds them) until they are added by scenario or topology is restored
+type scenarioUpdater struct {
+	g      *simulationGraph.GraphWrapper
+	events []ScenarioEvent
+	state  *scenarioState
+}
+
+// scenarioState - state of scenario updater (saved in checkpoints)
+type scenarioState struct {
+	Round     int
+	NextEvent int
+	Blocked   map[[2]int]struct{}
+}
+
+func init() {
+	registerSnapshotType(&scenarioState{})
+}
+
+func newScenarioUpdater(scenario *Scenario, g *simulationGraph.GraphWrapper) (*scenarioUpdater, error) {
+	if err := scenario.validate(g.GraphStructure.Order()); err != nil {
+		return nil, err
+	}
+
+	events := append([]ScenarioEvent{}, scenario.Events...)
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Round < events[j].Round })
+	return &scenarioUpdater{g: g, events: events,
+		state: &scenarioState{Blocked: map[[2]int]struct{}{}}}, nil
+}
+
+// edgeKey - returns edge with lower vertex first
+func edgeKey(v, w int) [2]int {
+	if v > w {
+		return [2]int{w, v}
+	}
+	return [2]int{v, w}
+}
+
+func (this *scenarioUpdater) UpdateEdges() []EdgeChange {
+	this.state.Round++
+	changes := make([]EdgeChange, 0)
+	remove := func(v, w int) {
+		this.state.Blocked[edgeKey(v, w)] = struct{}{}
+		if this.g.GraphStructure.Edge(v, w) {
+			this.g.GraphStructure.DeleteBoth(v, w)
+			changes = append(changes, EdgeChange{V: v, W: w, Removed: true})
+		}
+	}
+	add := func(v, w int) {
+		delete(this.state.Blocked, edgeKey(v, w))
+		if v != w && !this.g.GraphStructure.Edge(v, w) {
+			this.g.GraphStructure.AddBoth(v, w)
+			changes = append(changes, EdgeChange{V: v, W: w, Removed: false})
+		}
+	}
+
+	// blocked edges added by reliability model in this round are removed again
+	for _, e := range sortedKeys(this.state.Blocked) {
+		remove(e[0], e[1])
+	}
+
+	for ; this.state.NextEvent < len(this.events) && this.events[this.state.NextEvent].Round <= this.state.Round; this.state.NextEvent++ {
+		e := this.events[this.state.NextEvent]
+		switch e.Action {
+		case ScenarioRemove:
+			for _, edge := range e.Edges {
+				remove(edge[0], edge[1])
+			}
+		case ScenarioAdd:
+			for _, edge := range e.Edges {
+				add(edge[0], edge[1])
+			}
+		case ScenarioIsolate:
+			for _, edge := range this.candidateEdges() {
+				if edge[0] == e.Station || edge[1] == e.Station {
+					remove(edge[0], edge[1])
+				}
+			}
+		case ScenarioPartition:
+			group := make([]int, this.g.GraphStructure.Order())
+			for i, vertices := range e.Groups {
+				for _, v := range vertices {
+					group[v] = i + 1
+				}
+			}
+			for _, edge := range this.candidateEdges() {
+				if group[edge[0]] != group[edge[1]] {
+					remove(edge[0], edge[1])
+				}
+			}
+		case ScenarioRestore:
+			this.restore(remove, add)
+		}
+	}
+
+	return changes
+}
+
+// candidateEdges - returns current and original edges of graph
+func (this *scenarioUpdater) candidateEdges() [][2]int {
+	edges := map[[2]int]struct{}{}
+	for _, e := range currentEdges(this.g) {
+		edges[e] = struct{}{}
+	}
+	for _, e := range sortedEdges(this.g) {
+		edges[edgeKey(e[0], e[1])] = struct{}{}
+	}
+
+	return sortedKeys(edges)
+}
+
+// restore - removes edges which are not in original topology and adds missing original edges
+func (this *scenarioUpdater) restore(remove, add func(v, w int)) {
+	original := map[[2]int]struct{}{}
+	for _, e := range sortedEdges(this.g) {
+		original[edgeKey(e[0], e[1])] = struct{}{}
+	}
+	for _, e := range currentEdges(this.g) {
+		if _, ok := original[e]; !ok {
+			remove(e[0], e[1])
+		}
+	}
+	for _, e := range sortedKeys(original) {
+		add(e[0], e[1])
+	}
+	this.state.Blocked = map[[2]int]struct{}{}
+}
+
+// sortedKeys - returns edges of set in deterministic order
+func sortedKeys(set map[[2]int]struct{}) [][2]int {
+	edges := make([][2]int, 0, len(set))
+	for e := range set {
+		edges = append(edges, e)
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i][0] != edges[j][0] {
+			return edges[i][0] < edges[j][0]
+		}
+		return edges[i][1] < edges[j][1]
+	})
+
+	return edges
+}
+
+func (this *scenarioUpdater) saveState() interface{} {
+	return this.state
+}
+
+func (this *scenarioUpdater) restoreState(state interface{}) {
+	this.state = state.(*scenarioState)
+}
@@ -0,0 +1,97 @@
+package simulation
+
+import (
+	"app/simulationGraph"
+	"testing"
+)
+
+// edgeAdder - edge updater adding given edge in every round (link recovered by reliability model)
+type edgeAdder struct {
+	g    *simulationGraph.GraphWrapper
+	v, w int
+}
+
+func (u edgeAdder) UpdateEdges() []EdgeChange {
+	if u.g.GraphStructure.Edge(u.v, u.w) {
+		return nil
+	}
+	u.g.GraphStructure.AddBoth(u.v, u.w)
+	return []EdgeChange{{V: u.v, W: u.w, Removed: false}}
+}
+
+// checkEdges - checks presence of edges of 2x2 grid
+func checkEdges(t *testing.T, round int, g *simulationGraph.GraphWrapper, expected map[[2]int]bool) {
+	for e, present := range expected {
+		if g.GraphStructure.Edge(e[0], e[1]) != present {
+			t.Errorf("round %d: expected edge %d-%d present=%v", round, e[0], e[1], present)
+		}
+	}
+}
+
+func TestScenarioEvents(t *testing.T) {
+	// 2x2 grid with edges 0-1, 0-2, 1-3, 2-3
+	g := simulationGraph.BuildGrid(2, 2, "", "0")
+	scenario := &Scenario{Events: []ScenarioEvent{
+		{Round: 4, Action: ScenarioRestore},
+		{Round: 1, Action: ScenarioPartition, Groups: [][]int{{0, 1}}},
+		{Round: 2, Action: ScenarioAdd, Edges: [][2]int{{0, 3}}},
+		{Round: 3, Action: ScenarioIsolate, Station: 3},
+	}}
+	updater, err := newScenarioUpdater(scenario, g)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []map[[2]int]bool{
+		{{0, 1}: true, {0, 2}: false, {1, 3}: false, {2, 3}: true},
+		{{0, 1}: true, {0, 2}: false, {1, 3}: false, {2, 3}: true, {0, 3}: true},
+		{{0, 1}: true, {0, 2}: false, {1, 3}: false, {2, 3}: false, {0, 3}: false},
+		{{0, 1}: true, {0, 2}: true, {1, 3}: true, {2, 3}: true, {0, 3}: false},
+	}
+	for round, edges := range expected {
+		updater.UpdateEdges()
+		checkEdges(t, round+1, g, edges)
+	}
+}
+
+func TestScenarioBlocksEdgesAddedByReliabilityModel(t *testing.T) {
+	g := simulationGraph.BuildGrid(2, 2, "", "0")
+	scenario := &Scenario{Events: []ScenarioEvent{
+		{Round: 1, Action: ScenarioRemove, Edges: [][2]int{{0, 2}}},
+		{Round: 3, Action: ScenarioRestore},
+	}}
+	scenarioUpdater, err := newScenarioUpdater(scenario, g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	updater := compositeUpdater{updaters: []IEdgeUpdater{edgeAdder{g: g, v: 0, w: 2}, scenarioUpdater}}
+
+	// edge removed by scenario in round 1 stays removed in round 2 although model adds it back,
+	// changes of model and scenario cancel out
+	if changes := updater.UpdateEdges(); len(changes) != 1 || !changes[0].Removed {
+		t.Errorf("round 1: expected removal of edge 0-2, got %v", changes)
+	}
+	if changes := updater.UpdateEdges(); len(changes) != 0 || g.GraphStructure.Edge(0, 2) {
+		t.Errorf("round 2: expected blocked edge 0-2 without changes, got %v", changes)
+	}
+	if changes := updater.UpdateEdges(); len(changes) != 1 || changes[0].Removed || !g.GraphStructure.Edge(0, 2) {
+		t.Errorf("round 3: expected edge 0-2 restored, got %v", changes)
+	}
+}
+
+func TestScenarioValidation(t *testing.T) {
+	g := simulationGraph.BuildGrid(2, 2, "", "0")
+	invalid := []ScenarioEvent{
+		{Round: 0, Action: ScenarioRestore},
+		{Round: 1, Action: "split"},
+		{Round: 1, Action: ScenarioRemove, Edges: [][2]int{{0, 4}}},
+		{Round: 1, Action: ScenarioIsolate, Station: -1},
+		{Round: 1, Action: ScenarioPartition, Groups: [][]int{{0, 7}}},
+	}
+
+	for _, e := range invalid {
+		if _, err := newScenarioUpdater(&Scenario{Events: []ScenarioEvent{e}}, g); err == nil {
+			t.Errorf("expected error for event %+v", e)
+		}
+	}
+}
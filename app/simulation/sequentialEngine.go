@@ -0,0 +1,163 @@
+package simulation
+
+import (
+	"sync"
+)
+
+const (
+	// GoroutineEngine - every station runs in its own goroutine, rounds are synchronized by barrier
+	GoroutineEngine = "goroutines"
+	// SequentialEngine - stations are processed in a loop (or by fixed pool of workers) phase by phase
+	SequentialEngine = "sequential"
+)
+
+// workerPool - fixed set of workers processing disjoint parts of station list,
+// with single worker phases are run in the calling goroutine
+type workerPool struct {
+	nofWorkers int
+	tasks      []chan func()
+	wg         *sync.WaitGroup
+}
+
+func newWorkerPool(nofWorkers int) *workerPool {
+	pool := &workerPool{nofWorkers: nofWorkers, wg: &sync.WaitGroup{}}
+	if nofWorkers == 1 {
+		return pool
+	}
+
+	pool.tasks = make([]chan func(), nofWorkers)
+	for i := range pool.tasks {
+		pool.tasks[i] = make(chan func())
+		go func(tasks chan func()) {
+			for task := range tasks {
+				task()
+				pool.wg.Done()
+			}
+		}(pool.tasks[i])
+	}
+
+	return pool
+}
+
+// run - calls phase for every station and waits until all calls are finished
+func (pool *workerPool) run(stations []*SequentialStation, phase func(s *SequentialStation)) {
+	if pool.nofWorkers == 1 {
+		for _, s := range stations {
+			phase(s)
+		}
+		return
+	}
+
+	chunk := (len(stations) + pool.nofWorkers - 1) / pool.nofWorkers
+	for i := 0; i < pool.nofWorkers; i++ {
+		begin, end := i*chunk, (i+1)*chunk
+		if begin >= len(stations) {
+			break
+		}
+		if end > len(stations) {
+			end = len(stations)
+		}
+		part := stations[begin:end]
+		pool.wg.Add(1)
+		pool.tasks[i] <- func() {
+			for _, s := range part {
+				phase(s)
+			}
+		}
+	}
+	pool.wg.Wait()
+}
+
+func (pool *workerPool) close() {
+	for _, tasks := range pool.tasks {
+		close(tasks)
+	}
+}
+
+// runSequential - runs protocol round by round without goroutine per station,
+// phases are the same as in goroutine engine: stop check, edge update, receive, propagate
+func (m *Manager) runSequential(p Protocol) JsonStatsStructure {
+	pool := newWorkerPool(m.nofWorkers)
+	defer pool.close()
+
+	updater := m.edgeUpdater()
+
+	fingerprints := make([]uint64, m.nofStations)
+	stopping := make([]bool, m.nofStations)
+
+	if m.resumeFrom != nil {
+		m.restoreCheckpoint(m.resumeFrom, fingerprints)
+	} else {
+		m.startRound(0)
+		pool.run(m.sequentialStations(), func(s *SequentialStation) {
+			s.seedRandom(m.seed)
+			p.GetInitialData(s)
+		})
+		// round 0
+		pool.run(m.sequentialStations(), func(s *SequentialStation) {
+			p.OnInitialize(s)
+			m.recordState(p, s, &fingerprints[s.id])
+		})
+	}
+
+	active := make([]*SequentialStation, 0, m.nofStations)
+	for _, s := range m.sequentialStations() {
+		if !s.stopped {
+			active = append(active, s)
+		}
+	}
+
+	for len(active) > 0 {
+		if m.checkpointDue() {
+			m.checkpointErr = m.saveCheckpoint(fingerprints)
+		}
+
+		pool.run(active, func(s *SequentialStation) { stopping[s.id] = !m.continueRunning(p, s) })
+		stillActive := active[:0]
+		for _, s := range active {
+			if stopping[s.id] {
+				s.stop()
+			} else {
+				stillActive = append(stillActive, s)
+			}
+		}
+		active = stillActive
+		if len(active) == 0 {
+			break
+		}
+
+		if updater != nil {
+			m.pendingChanges = append(m.pendingChanges, updateEdges(updater)...)
+		}
+		m.advanceRound()
+		if m.terminated {
+			for _, s := range active {
+				s.stop()
+			}
+			break
+		}
+
+		pool.run(active, func(s *SequentialStation) {
+			s.swapMailboxes()
+			p.OnDataReceive(s)
+		})
+		pool.run(active, func(s *SequentialStation) {
+			p.OnDataPropagate(s)
+			m.recordState(p, s, &fingerprints[s.id])
+			s.RoundCounter++
+		})
+	}
+
+	m.endRound()
+	pool.run(m.sequentialStations(), func(s *SequentialStation) { s.finalize(p) })
+	return m.makeStatsSummary(p)
+}
+
+func (m *Manager) sequentialStations() []*SequentialStation {
+	stations := make([]*SequentialStation, 0, len(*m.stations))
+	for _, s := range *m.stations {
+		stations = append(stations, s.(*SequentialStation))
+	}
+
+	return stations
+}
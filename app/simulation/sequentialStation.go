@@ -0,0 +1,150 @@
+package simulation
+
+import (
+	"app/simulationGraph"
+	"sync"
+	"sync/atomic"
+)
+
+// SequentialStation - station driven by sequential engine, messages are stored in double-buffered
+// mailboxes: inbox holds messages received in current round, nextInbox collects messages sent
+// in current round, buffers are swapped at the beginning of every round
+type SequentialStation struct {
+	*Station
+	manager   *Manager
+	inbox     []*Pack
+	nextInbox []*Pack
+	mutex     *sync.Mutex
+	// maxQueueSize - maximal number of float64 values in message queue
+	maxQueueSize int
+	stopped      bool
+}
+
+func NewSequentialStation(manager *Manager, id int, g *simulationGraph.GraphWrapper) *SequentialStation {
+	return &SequentialStation{Station: NewStation(id, g),
+		manager:   manager,
+		inbox:     make([]*Pack, 0),
+		nextInbox: make([]*Pack, 0),
+		mutex:     &sync.Mutex{}}
+}
+
+// swapMailboxes - moves messages sent in previous round to message queue
+func (this *SequentialStation) swapMailboxes() {
+	this.inbox, this.nextInbox = this.nextInbox, this.inbox[:0]
+	sortBySender(this.inbox)
+	if this.manager.orderInbox != nil {
+		this.manager.orderInbox(this.manager.round, this.id, this.inbox)
+	}
+	for _, msg := range this.inbox {
+		this.historicalDataForStats = append(this.historicalDataForStats, msg.Data)
+		this.msgQueue.Enqueue(msg)
+		this.ReceivedMsgCounter += len(msg.Data)
+		this.manager.observers.messageDelivered(this.manager.round, msg, this.id)
+	}
+	if dataLen := this.msgQueue.DataLen(); dataLen > this.maxQueueSize {
+		this.maxQueueSize = dataLen
+	}
+}
+
+// stop - marks station as stopped, messages waiting for next round are counted as undelivered
+func (this *SequentialStation) stop() {
+	this.mutex.Lock()
+	this.stopped = true
+	for _, msg := range this.nextInbox {
+		this.UndeliveredMsgCounter += len(msg.Data)
+		this.manager.observers.messageDropped(this.manager.round, msg, this.id)
+	}
+	this.nextInbox = this.nextInbox[:0]
+	this.mutex.Unlock()
+}
+
+// finalize - wrap-up round
+func (this *SequentialStation) finalize(protocol Protocol) {
+	protocol.OnFinalize(this)
+	this.ExactResult = protocol.CalculateStationExactResult(this)
+	this.countMemory(this.maxQueueSize)
+}
+
+// deliver - puts pack into receiver's next inbox, packs sent to stopped station are discarded
+func (this *SequentialStation) deliver(receiverId int, pack *Pack) {
+	s := this.manager.getStationById(receiverId).(*SequentialStation)
+	s.mutex.Lock()
+	if s.stopped {
+		s.UndeliveredMsgCounter += len(pack.Data)
+		this.manager.observers.messageDropped(this.manager.round, pack, receiverId)
+	} else {
+		s.nextInbox = append(s.nextInbox, pack)
+	}
+	s.mutex.Unlock()
+	this.SentMsgCounter += len(pack.Data)
+	atomic.AddInt64(&this.manager.roundSentMsgs, 1)
+	this.manager.observers.messageSent(this.manager.round, pack, receiverId)
+}
+
+// Broadcast - function used for broadcasting information to neighbours
+func (this *SequentialStation) Broadcast() {
+	pack := NewPack(this.currentData, this.RoundCounter, this.id)
+	this.graph.GraphStructure.Visit(this.id, func(w int, c int64) (skip bool) {
+		this.deliver(w, pack)
+		return
+	})
+}
+
+// SynchronizedBroadcast - the same as Broadcast, mailboxes are always thread-safe
+func (this *SequentialStation) SynchronizedBroadcast() {
+	this.Broadcast()
+}
+
+// Send - sends given data to neighbour, returns false if link to receiver is broken
+func (this *SequentialStation) Send(receiverId int, data []float64) bool {
+	if !this.graph.GraphStructure.Edge(this.id, receiverId) {
+		return false
+	}
+
+	this.deliver(receiverId, NewPack(data, this.RoundCounter, this.id))
+	return true
+}
+
+func (this *SequentialStation) GetStation() Station {
+	return *this.Station
+}
+
+// snapshot - returns state of station saved in checkpoint
+func (this *SequentialStation) snapshot() stationSnapshot {
+	return stationSnapshot{
+		CurrentData:           this.currentData,
+		HistoricalData:        this.historicalDataForStats,
+		ObservedValues:        this.observedValues,
+		UserDefinedVariables:  this.userDefinedVariables,
+		State:                 this.state,
+		Queue:                 this.msgQueue.queue,
+		NextInbox:             this.nextInbox,
+		SentMsgCounter:        this.SentMsgCounter,
+		ReceivedMsgCounter:    this.ReceivedMsgCounter,
+		UndeliveredMsgCounter: this.UndeliveredMsgCounter,
+		RoundCounter:          this.RoundCounter,
+		Result:                this.Result,
+		MaxQueueSize:          this.maxQueueSize,
+		Stopped:               this.stopped,
+		Random:                this.randomSource.state(),
+	}
+}
+
+// restore - sets state of station saved in checkpoint
+func (this *SequentialStation) restore(s stationSnapshot) {
+	this.currentData = s.CurrentData
+	this.historicalDataForStats = s.HistoricalData
+	this.observedValues = s.ObservedValues
+	this.userDefinedVariables = s.UserDefinedVariables
+	this.state = s.State
+	this.msgQueue.queue = s.Queue
+	this.nextInbox = s.NextInbox
+	this.SentMsgCounter = s.SentMsgCounter
+	this.ReceivedMsgCounter = s.ReceivedMsgCounter
+	this.UndeliveredMsgCounter = s.UndeliveredMsgCounter
+	this.RoundCounter = s.RoundCounter
+	this.Result = s.Result
+	this.maxQueueSize = s.MaxQueueSize
+	this.stopped = s.Stopped
+	this.randomSource.restore(s.Random)
+}
@@ -0,0 +1,266 @@
+package simulation
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"unsafe"
+)
+
+// snapshotTypes - types of values which can be stored in interfaces (user defined variables) of snapshots
+var snapshotTypes = map[string]reflect.Type{}
+
+func init() {
+	registerSnapshotType(false, 0, 0., "", []bool{}, []int{}, []float64{}, [][]float64{},
+		map[int]int{}, map[int]float64{}, map[int][]float64{}, map[int]struct{}{}, map[float64]int{})
+}
+
+// registerSnapshotType - makes types of given values available for snapshots, protocols register
+// types of their user defined variables which are not registered here
+func registerSnapshotType(values ...interface{}) {
+	for _, v := range values {
+		t := reflect.TypeOf(v)
+		snapshotTypes[t.String()] = t
+	}
+}
+
+// snapshotEncoder - writes values of any type, including unexported struct fields, in binary form,
+// values stored in interfaces are written with name of their type, functions and channels are skipped
+type snapshotEncoder struct {
+	w   *bufio.Writer
+	buf [binary.MaxVarintLen64]byte
+	err error
+}
+
+func newSnapshotEncoder(w io.Writer) *snapshotEncoder {
+	return &snapshotEncoder{w: bufio.NewWriter(w)}
+}
+
+// flush - writes buffered data, returns first error which occurred during encoding
+func (e *snapshotEncoder) flush() error {
+	if e.err == nil {
+		e.err = e.w.Flush()
+	}
+	return e.err
+}
+
+func (e *snapshotEncoder) uvarint(x uint64) {
+	if e.err == nil {
+		_, e.err = e.w.Write(e.buf[:binary.PutUvarint(e.buf[:], x)])
+	}
+}
+
+func (e *snapshotEncoder) varint(x int64) {
+	if e.err == nil {
+		_, e.err = e.w.Write(e.buf[:binary.PutVarint(e.buf[:], x)])
+	}
+}
+
+func (e *snapshotEncoder) string(s string) {
+	e.uvarint(uint64(len(s)))
+	if e.err == nil {
+		_, e.err = e.w.WriteString(s)
+	}
+}
+
+// length - writes length of slice or map, 0 means nil
+func (e *snapshotEncoder) length(v reflect.Value) bool {
+	if v.IsNil() {
+		e.uvarint(0)
+		return false
+	}
+	e.uvarint(uint64(v.Len()) + 1)
+	return true
+}
+
+// encode - writes value of given pointer
+func (e *snapshotEncoder) encode(value interface{}) {
+	e.value(reflect.ValueOf(value).Elem())
+}
+
+func (e *snapshotEncoder) value(v reflect.Value) {
+	if e.err != nil {
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			e.uvarint(1)
+		} else {
+			e.uvarint(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.varint(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.uvarint(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		e.uvarint(math.Float64bits(v.Float()))
+	case reflect.String:
+		e.string(v.String())
+	case reflect.Slice:
+		// capacity is kept, memory of restored station is counted the same way
+		if e.length(v) {
+			e.uvarint(uint64(v.Cap()))
+			for i := 0; i < v.Len(); i++ {
+				e.value(v.Index(i))
+			}
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			e.value(v.Index(i))
+		}
+	case reflect.Map:
+		if e.length(v) {
+			for it := v.MapRange(); it.Next(); {
+				e.value(it.Key())
+				e.value(it.Value())
+			}
+		}
+	case reflect.Ptr:
+		if v.IsNil() {
+			e.uvarint(0)
+		} else {
+			e.uvarint(1)
+			e.value(v.Elem())
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			e.value(v.Field(i))
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			e.string("")
+			return
+		}
+		name := v.Elem().Type().String()
+		if _, ok := snapshotTypes[name]; !ok {
+			e.err = fmt.Errorf("type %s is not registered for snapshots", name)
+			return
+		}
+		e.string(name)
+		e.value(v.Elem())
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+	default:
+		e.err = fmt.Errorf("type %s is not supported by snapshots", v.Type())
+	}
+}
+
+// snapshotDecoder - reads values written by snapshotEncoder
+type snapshotDecoder struct {
+	r   *bufio.Reader
+	err error
+}
+
+func newSnapshotDecoder(r io.Reader) *snapshotDecoder {
+	return &snapshotDecoder{r: bufio.NewReader(r)}
+}
+
+func (d *snapshotDecoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	x, err := binary.ReadUvarint(d.r)
+	d.err = err
+	return x
+}
+
+func (d *snapshotDecoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	x, err := binary.ReadVarint(d.r)
+	d.err = err
+	return x
+}
+
+func (d *snapshotDecoder) string() string {
+	n := d.uvarint()
+	if d.err != nil {
+		return ""
+	}
+	b := make([]byte, n)
+	_, d.err = io.ReadFull(d.r, b)
+	return string(b)
+}
+
+// decode - reads value into given pointer
+func (d *snapshotDecoder) decode(value interface{}) error {
+	d.value(reflect.ValueOf(value).Elem())
+	if d.err == io.EOF || d.err == io.ErrUnexpectedEOF {
+		d.err = fmt.Errorf("snapshot is truncated")
+	}
+	return d.err
+}
+
+// value - reads value into v, unexported struct fields are set through their addresses
+func (d *snapshotDecoder) value(v reflect.Value) {
+	if d.err != nil {
+		return
+	}
+	if !v.CanSet() {
+		v = reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(d.uvarint() != 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(d.varint())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		v.SetUint(d.uvarint())
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(math.Float64frombits(d.uvarint()))
+	case reflect.String:
+		v.SetString(d.string())
+	case reflect.Slice:
+		if n := int(d.uvarint()); n > 0 {
+			v.Set(reflect.MakeSlice(v.Type(), n-1, int(d.uvarint())))
+			for i := 0; i < n-1; i++ {
+				d.value(v.Index(i))
+			}
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			d.value(v.Index(i))
+		}
+	case reflect.Map:
+		if n := int(d.uvarint()); n > 0 {
+			v.Set(reflect.MakeMapWithSize(v.Type(), n-1))
+			for i := 0; i < n-1 && d.err == nil; i++ {
+				key, elem := reflect.New(v.Type().Key()).Elem(), reflect.New(v.Type().Elem()).Elem()
+				d.value(key)
+				d.value(elem)
+				v.SetMapIndex(key, elem)
+			}
+		}
+	case reflect.Ptr:
+		if d.uvarint() != 0 {
+			v.Set(reflect.New(v.Type().Elem()))
+			d.value(v.Elem())
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			d.value(v.Field(i))
+		}
+	case reflect.Interface:
+		name := d.string()
+		if name == "" || d.err != nil {
+			return
+		}
+		t, ok := snapshotTypes[name]
+		if !ok {
+			d.err = fmt.Errorf("type %s is not registered for snapshots", name)
+			return
+		}
+		elem := reflect.New(t).Elem()
+		d.value(elem)
+		v.Set(elem)
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+	default:
+		d.err = fmt.Errorf("type %s is not supported by snapshots", v.Type())
+	}
+}
@@ -2,30 +2,36 @@ package simulation
 
 import (
 	"app/simulationGraph"
-	"sync"
+	"github.com/DmitriyVTitov/size"
+	"go/types"
+	"math/rand"
+	"sort"
 )
 
 // IStation - interface used for station module
 type IStation interface {
-	// RunProtocol - runs protocol
-	RunProtocol(protocol Protocol, wg *sync.WaitGroup, reliabilityModel string, updateBegin chan bool,
-		updateFinish chan bool)
 	// GetId - returns station id
 	GetId() int
 	// Broadcast - sends msg to station neighbours
 	Broadcast()
 	// SynchronizedBroadcast - sends msg to station neighbours (threadsafe)
 	SynchronizedBroadcast()
+	// Send - sends msg with given data to neighbour (threadsafe), returns false if link is broken
+	Send(receiverId int, data []float64) bool
+	// GetNeighbours - returns ids of currently connected neighbours in ascending order
+	GetNeighbours() []int
 	// SetCurrentData - sets current vector data in station
 	SetCurrentData(data []float64)
 	// GetCurrentData - returns current vector data in station
 	GetCurrentData() []float64
-	// GetMsgQueue - returns station's message queue
+	// GetMsgQueue - returns station's message queue, data of received messages must not be modified
 	GetMsgQueue() *MessageQueue
 	// GetSentMsgCounter - returns sent message counter
 	GetSentMsgCounter() int
 	// GetReceivedMsgCounter - returns received message counter
 	GetReceivedMsgCounter() int
+	// GetUndeliveredMsgCounter - returns counter of messages discarded because station already stopped
+	GetUndeliveredMsgCounter() int
 	// GetMemoryCounter - returns memory counter
 	GetMemoryCounter() int
 	// GetRoundCounter - returns round counter
@@ -34,6 +40,13 @@ type IStation interface {
 	SetUserDefinedVariable(key string, value interface{})
 	// GetUserDefinedVariable - gets user defined variable by name
 	GetUserDefinedVariable(key string) interface{}
+	// SetState - sets typed state of station (see StatefulProtocol)
+	SetState(state interface{})
+	// GetState - returns typed state of station, nil if protocol does not declare state
+	GetState() interface{}
+	// GetRandom - returns random generator of station, every station has its own stream derived from seed
+	// of simulation, so drawn values do not depend on order in which stations are run
+	GetRandom() *rand.Rand
 	// GetGraph - returns graph topology
 	GetGraph() *simulationGraph.GraphWrapper
 	// GetHistoricalDataForStats - returns historical data for statistics
@@ -59,8 +72,12 @@ type Station struct {
 	graph                  *simulationGraph.GraphWrapper
 	SentMsgCounter         int `json:"sent_msgs"`
 	ReceivedMsgCounter     int `json:"received_msgs"`
+	UndeliveredMsgCounter  int `json:"undelivered_msgs"`
 	RoundCounter           int `json:"nof_rounds"`
 	userDefinedVariables   map[string]interface{}
+	state                  interface{}
+	randomSource           *countingSource
+	random                 *rand.Rand
 	Result                 float64 `json:"result"`
 	ExactResult            float64 `json:"exact_result"`
 	MemoryCounter          int     `json:"memory"`
@@ -68,6 +85,7 @@ type Station struct {
 
 func NewStation(id int, graph *simulationGraph.GraphWrapper) *Station {
 	nofNeighbours := graph.GraphStructure.Degree(id)
+	randomSource := newCountingSource(int64(id))
 	return &Station{id: id,
 		nofNeighbours:          nofNeighbours,
 		msgQueue:               NewMessageQueue(),
@@ -79,6 +97,8 @@ func NewStation(id int, graph *simulationGraph.GraphWrapper) *Station {
 		ReceivedMsgCounter:     0,
 		RoundCounter:           0,
 		userDefinedVariables:   make(map[string]interface{}),
+		randomSource:           randomSource,
+		random:                 rand.New(randomSource),
 		MemoryCounter:          0}
 }
 
@@ -107,6 +127,10 @@ func (this *Station) GetReceivedMsgCounter() int {
 	return this.ReceivedMsgCounter
 }
 
+func (this *Station) GetUndeliveredMsgCounter() int {
+	return this.UndeliveredMsgCounter
+}
+
 func (this *Station) GetMemoryCounter() int {
 	return this.MemoryCounter
 }
@@ -123,6 +147,35 @@ func (this *Station) GetUserDefinedVariable(key string) interface{} {
 	return this.userDefinedVariables[key]
 }
 
+func (this *Station) SetState(state interface{}) {
+	this.state = state
+}
+
+func (this *Station) GetState() interface{} {
+	return this.state
+}
+
+func (this *Station) GetRandom() *rand.Rand {
+	return this.random
+}
+
+// seedRandom - seeds random generator of station with stream derived from seed of simulation
+func (this *Station) seedRandom(seed int64) {
+	this.randomSource.Seed(stationSeed(seed, this.id))
+}
+
+func (this *Station) GetNeighbours() []int {
+	neighbours := make([]int, 0, this.nofNeighbours)
+	this.graph.GraphStructure.Visit(this.id, func(w int, c int64) (skip bool) {
+		neighbours = append(neighbours, w)
+		return
+	})
+	// graph visits neighbours in random order, sorting makes seeded runs reproducible
+	sort.Ints(neighbours)
+
+	return neighbours
+}
+
 func (this *Station) GetGraph() *simulationGraph.GraphWrapper {
 	return this.graph
 }
@@ -142,3 +195,19 @@ func (this *Station) ObserveValue(value []float64) {
 func (this *Station) GetObservedValues() [][]float64 {
 	return this.observedValues
 }
+
+// countMemory - sets memory counter (in number of float64 values) from typed state declared by protocol,
+// user defined variables, maximal number of values in message queue, current data and observed values
+func (this *Station) countMemory(maxQueueSize int) {
+	if this.state != nil {
+		this.MemoryCounter += size.Of(this.state) / size.Of(types.Float64)
+	}
+	if this.state == nil || len(this.userDefinedVariables) > 0 {
+		this.MemoryCounter += size.Of(this.userDefinedVariables) / size.Of(types.Float64)
+	}
+	this.MemoryCounter += maxQueueSize
+	this.MemoryCounter += len(this.currentData)
+	if len(this.observedValues) > 0 {
+		this.MemoryCounter += len(this.observedValues) * len(this.observedValues[0])
+	}
+}
@@ -0,0 +1,113 @@
+package simulation
+
+// StatefulProtocol - protocol declaring type S of state kept by every station, state is created
+// for every station before GetInitialData and passed to all station phases, WithState converts
+// stateful protocol to Protocol
+type StatefulProtocol[S any] interface {
+	// GetInitialData - phase in which each station generates initial data and initializes its state
+	GetInitialData(station IStation, state *S)
+	// OnInitialize - phase in which round 0 is performed (setup for next protocol rounds)
+	OnInitialize(station IStation, state *S)
+	// OnDataReceive - in this phase stations read messages (if messages are present)
+	OnDataReceive(station IStation, state *S)
+	// OnDataPropagate - in this phase stations propagate some information
+	OnDataPropagate(station IStation, state *S)
+	// StopCondition - stop condition defines when protocol is finished
+	StopCondition(station IStation, state *S) bool
+	// OnFinalize - phase in which wrap-up round is performed
+	OnFinalize(station IStation, state *S)
+	// CalculateStationExactResult - function used to calculate exact station result
+	CalculateStationExactResult(station IStation, state *S) float64
+	// CalculateGlobalExactResult - function used to calculate global result using all stations (see StateOf)
+	CalculateGlobalExactResult(stations *[]IStation) float64
+}
+
+// StatefulStateChangeReporter - optional interface of stateful protocols which know when station's
+// state changes (see StateChangeReporter)
+type StatefulStateChangeReporter[S any] interface {
+	// StateChanged - returns true if station changed its state in current round
+	StateChanged(station IStation, state *S) bool
+}
+
+// statefulProtocol - adapter of StatefulProtocol to Protocol
+type statefulProtocol[S any] struct {
+	p StatefulProtocol[S]
+}
+
+// reportingStatefulProtocol - adapter of StatefulProtocol which reports state changes
+type reportingStatefulProtocol[S any] struct {
+	statefulProtocol[S]
+	reporter StatefulStateChangeReporter[S]
+}
+
+// WithState - returns Protocol running given stateful protocol, the state type is registered for checkpoints
+func WithState[S any](p StatefulProtocol[S]) Protocol {
+	registerSnapshotType(new(S))
+	adapter := statefulProtocol[S]{p: p}
+	if reporter, ok := p.(StatefulStateChangeReporter[S]); ok {
+		return reportingStatefulProtocol[S]{statefulProtocol: adapter, reporter: reporter}
+	}
+
+	return adapter
+}
+
+// StateOf - returns typed state of station created by protocol returned by WithState
+func StateOf[S any](station IStation) *S {
+	return station.GetState().(*S)
+}
+
+func (a statefulProtocol[S]) GetInitialData(station IStation) {
+	state := new(S)
+	station.SetState(state)
+	a.p.GetInitialData(station, state)
+}
+
+func (a statefulProtocol[S]) OnInitialize(station IStation) {
+	a.p.OnInitialize(station, StateOf[S](station))
+}
+
+func (a statefulProtocol[S]) OnDataReceive(station IStation) {
+	a.p.OnDataReceive(station, StateOf[S](station))
+}
+
+func (a statefulProtocol[S]) OnDataPropagate(station IStation) {
+	a.p.OnDataPropagate(station, StateOf[S](station))
+}
+
+func (a statefulProtocol[S]) StopCondition(station IStation) bool {
+	return a.p.StopCondition(station, StateOf[S](station))
+}
+
+func (a statefulProtocol[S]) OnFinalize(station IStation) {
+	a.p.OnFinalize(station, StateOf[S](station))
+}
+
+func (a statefulProtocol[S]) CalculateStationExactResult(station IStation) float64 {
+	return a.p.CalculateStationExactResult(station, StateOf[S](station))
+}
+
+func (a statefulProtocol[S]) CalculateGlobalExactResult(stations *[]IStation) float64 {
+	return a.p.CalculateGlobalExactResult(stations)
+}
+
+func (a reportingStatefulProtocol[S]) StateChanged(station IStation) bool {
+	return a.reporter.StateChanged(station, StateOf[S](station))
+}
+
+// CalculateProtocolStats - returns statistics of wrapped protocol if it provides them
+func (a statefulProtocol[S]) CalculateProtocolStats(stations *[]IStation) map[string]interface{} {
+	if statsProvider, ok := a.p.(ProtocolStatsProvider); ok {
+		return statsProvider.CalculateProtocolStats(stations)
+	}
+
+	return nil
+}
+
+// ValidateGraph - validates wrapped protocol if it implements GraphValidator
+func (a statefulProtocol[S]) ValidateGraph(nofStations int) error {
+	if validator, ok := a.p.(GraphValidator); ok {
+		return validator.ValidateGraph(nofStations)
+	}
+
+	return nil
+}
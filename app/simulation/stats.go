@@ -1,24 +1,76 @@
 package simulation
 
+import (
+	"fmt"
+	"math"
+)
+
 // JsonStatsStructure - structure for saving statistics to file
 type JsonStatsStructure struct {
-	Size               int       `json:"size"`
-	Result             float64   `json:"result"`
-	NofRounds          int       `json:"nof_rounds"`
-	MaxReceivedMsgs    int       `json:"max_received_msgs"`
-	MinReceivedMsgs    int       `json:"min_received_msgs"`
-	AllReceivedMsgs    int       `json:"all_received_msgs"`
-	AvgReceivedMsgs    float64   `json:"avg_received_msgs"`
-	StddevReceivedMsgs float64   `json:"stddev_received_msgs"`
-	MaxSentMsgs        int       `json:"max_sent_msgs"`
-	MinSentMsgs        int       `json:"min_sent_msgs"`
-	AllSentMsgs        int       `json:"all_sent_msgs"`
-	AvgSentMsgs        float64   `json:"avg_sent_msgs"`
-	StddevSentMsgs     float64   `json:"stddev_sent_msgs"`
-	AllMemory          int       `json:"all_memory"`
-	MaxMemory          int       `json:"max_memory"`
-	MinMemory          int       `json:"min_memory"`
-	AvgMemory          float64   `json:"avg_memory"`
-	StddevMemory       float64   `json:"stddev_memory"`
-	Stations           []Station `json:"stations"`
+	Size               int                    `json:"size"`
+	Result             float64                `json:"result"`
+	NofRounds          int                    `json:"nof_rounds"`
+	MaxReceivedMsgs    int                    `json:"max_received_msgs"`
+	MinReceivedMsgs    int                    `json:"min_received_msgs"`
+	AllReceivedMsgs    int                    `json:"all_received_msgs"`
+	AvgReceivedMsgs    float64                `json:"avg_received_msgs"`
+	StddevReceivedMsgs float64                `json:"stddev_received_msgs"`
+	MaxSentMsgs        int                    `json:"max_sent_msgs"`
+	MinSentMsgs        int                    `json:"min_sent_msgs"`
+	AllSentMsgs        int                    `json:"all_sent_msgs"`
+	AvgSentMsgs        float64                `json:"avg_sent_msgs"`
+	StddevSentMsgs     float64                `json:"stddev_sent_msgs"`
+	AllUndeliveredMsgs int                    `json:"all_undelivered_msgs"`
+	AllMemory          int                    `json:"all_memory"`
+	MaxMemory          int                    `json:"max_memory"`
+	MinMemory          int                    `json:"min_memory"`
+	AvgMemory          float64                `json:"avg_memory"`
+	StddevMemory       float64                `json:"stddev_memory"`
+	Stations           []Station              `json:"stations"`
+	ProtocolStats      map[string]interface{} `json:"protocol_stats,omitempty"`
+	ReliabilityStats   map[string]interface{} `json:"reliability_stats,omitempty"`
+}
+
+// DiffStats - compares statistics of two runs of the same simulation, returns description
+// of the first difference or empty string if runs are equal
+func DiffStats(a, b JsonStatsStructure) string {
+	if a.NofRounds != b.NofRounds {
+		return fmt.Sprintf("number of rounds %d != %d", a.NofRounds, b.NofRounds)
+	}
+	if a.AllSentMsgs != b.AllSentMsgs || a.AllReceivedMsgs != b.AllReceivedMsgs || a.AllUndeliveredMsgs != b.AllUndeliveredMsgs {
+		return fmt.Sprintf("messages sent/received/undelivered %d/%d/%d != %d/%d/%d", a.AllSentMsgs, a.AllReceivedMsgs,
+			a.AllUndeliveredMsgs, b.AllSentMsgs, b.AllReceivedMsgs, b.AllUndeliveredMsgs)
+	}
+	if a.AllMemory != b.AllMemory || a.MaxMemory != b.MaxMemory {
+		return fmt.Sprintf("memory all/max %d/%d != %d/%d", a.AllMemory, a.MaxMemory, b.AllMemory, b.MaxMemory)
+	}
+	if !sameFloat(a.Result, b.Result) {
+		return fmt.Sprintf("result %v != %v", a.Result, b.Result)
+	}
+	if len(a.Stations) != len(b.Stations) {
+		return fmt.Sprintf("number of stations %d != %d", len(a.Stations), len(b.Stations))
+	}
+	for i := range a.Stations {
+		s, t := a.Stations[i], b.Stations[i]
+		if s.SentMsgCounter != t.SentMsgCounter || s.ReceivedMsgCounter != t.ReceivedMsgCounter ||
+			s.RoundCounter != t.RoundCounter || !sameFloat(s.Result, t.Result) {
+			return fmt.Sprintf("station %d: sent/received/rounds/result %d/%d/%d/%v != %d/%d/%d/%v", i,
+				s.SentMsgCounter, s.ReceivedMsgCounter, s.RoundCounter, s.Result,
+				t.SentMsgCounter, t.ReceivedMsgCounter, t.RoundCounter, t.Result)
+		}
+	}
+	// maps are printed with sorted keys
+	if x, y := fmt.Sprint(a.ProtocolStats), fmt.Sprint(b.ProtocolStats); x != y {
+		return fmt.Sprintf("protocol stats %s != %s", x, y)
+	}
+	if x, y := fmt.Sprint(a.ReliabilityStats), fmt.Sprint(b.ReliabilityStats); x != y {
+		return fmt.Sprintf("reliability stats %s != %s", x, y)
+	}
+
+	return ""
+}
+
+// sameFloat - returns true if values are equal, NaN is equal to NaN
+func sameFloat(a, b float64) bool {
+	return a == b || (math.IsNaN(a) && math.IsNaN(b))
 }
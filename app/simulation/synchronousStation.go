@@ -2,70 +2,91 @@ package simulation
 
 import (
 	"app/simulationGraph"
-	"github.com/DmitriyVTitov/size"
-	"go/types"
 	"sync"
+	"sync/atomic"
 )
 
 // SynchronousStation - station used for implementing synchronous protocols
 type SynchronousStation struct {
 	*Station
-	manager              *Manager
-	communicationChannel chan *Pack
-	mutex                *sync.Mutex
-	maxQueueSize         int
+	manager *Manager
+	// nextInbox - messages sent to station in current round, received in the next round,
+	// it is not bounded, so station can receive any number of messages from neighbour
+	nextInbox []*Pack
+	mutex     *sync.Mutex
+	// maxQueueSize - maximal number of float64 values in message queue
+	maxQueueSize int
+	// stopped - station finished running protocol, messages sent to it are discarded
+	stopped bool
 }
 
 func NewSynchronousStation(manager *Manager, id int, g *simulationGraph.GraphWrapper) *SynchronousStation {
-	nofNeighbours := g.GraphStructure.Degree(id)
-	communicationChannel := make(chan *Pack, nofNeighbours)
-
 	return &SynchronousStation{NewStation(id, g),
 		manager,
-		communicationChannel,
+		make([]*Pack, 0),
 		&sync.Mutex{},
-		0}
+		0,
+		false}
 }
 
 // RunProtocol - runs protocol
-func (this *SynchronousStation) RunProtocol(protocol Protocol, wg *sync.WaitGroup, reliabilityModel string, updateBegin chan bool,
+func (this *SynchronousStation) RunProtocol(protocol Protocol, wg *sync.WaitGroup, updatesEdges bool, updateBegin chan bool,
 	updateFinish chan bool) {
 	defer wg.Done()
 	protocol.GetInitialData(this)
 	// round 0
 	protocol.OnInitialize(this)
+	fingerprint := uint64(0)
+	this.manager.recordState(protocol, this, &fingerprint)
 
 	// concrete rounds
-	for protocol.StopCondition(this) {
-		if reliabilityModel != "" {
+	for this.manager.continueRunning(protocol, this) {
+		if updatesEdges {
 			this.waitForUpdate(updateBegin, updateFinish)
 		}
 
 		this.manager.b.WaitAtFirstBarrier()
-		close(this.communicationChannel)
+		if this.manager.terminated {
+			this.manager.b.WaitAtSecondBarrier()
+			break
+		}
 		this.receiveMsgs()
 		this.updateMaxQueueSizeIfNecessary()
 		protocol.OnDataReceive(this)
-
-		this.communicationChannel = make(chan *Pack, this.nofNeighbours)
 		this.manager.b.WaitAtSecondBarrier()
 
 		protocol.OnDataPropagate(this)
+		this.manager.recordState(protocol, this, &fingerprint)
 		this.RoundCounter++
 	}
 
+	// station stops independently of other stations
+	this.stop(updatesEdges, updateBegin)
+	<-this.manager.allStopped
+
 	// sum up round
-	this.manager.b.WaitAtFirstBarrier()
 	protocol.OnFinalize(this)
-	close(this.communicationChannel)
 	this.ExactResult = protocol.CalculateStationExactResult(this)
-	this.MemoryCounter += size.Of(this.userDefinedVariables) / size.Of(types.Float64)
-	this.MemoryCounter += this.maxQueueSize
-	this.MemoryCounter += len(this.currentData)
-	if len(this.observedValues) > 0 {
-		this.MemoryCounter += len(this.observedValues) * len(this.observedValues[0])
+	this.countMemory(this.maxQueueSize)
+}
+
+// stop - marks station as stopped, messages waiting for next round are counted as undelivered,
+// station leaves barrier and edge updating so remaining stations can continue
+func (this *SynchronousStation) stop(updatesEdges bool, updateBegin chan bool) {
+	this.mutex.Lock()
+	this.stopped = true
+	for _, msg := range this.nextInbox {
+		this.UndeliveredMsgCounter += len(msg.Data)
+		this.manager.observers.messageDropped(this.manager.round, msg, this.id)
+	}
+	this.nextInbox = nil
+	this.mutex.Unlock()
+
+	if updatesEdges {
+		updateBegin <- false
 	}
-	this.manager.b.WaitAtSecondBarrier()
+	this.manager.b.Deregister()
+	this.manager.running.Done()
 }
 
 func (this *SynchronousStation) waitForUpdate(updateBegin chan bool, updateFinish chan bool) {
@@ -74,45 +95,73 @@ func (this *SynchronousStation) waitForUpdate(updateBegin chan bool, updateFinis
 }
 
 func (this *SynchronousStation) updateMaxQueueSizeIfNecessary() {
-	if this.msgQueue.Len() > this.maxQueueSize {
-		this.maxQueueSize = this.msgQueue.Len()
+	if dataLen := this.msgQueue.DataLen(); dataLen > this.maxQueueSize {
+		this.maxQueueSize = dataLen
 	}
 }
 
-func (this *SynchronousStation) sendMsgToStation(receiverId int) {
-	packToSend := NewPack(this.currentData, this.RoundCounter)
+// deliver - puts pack into receiver's channel, packs sent to stopped station are discarded
+func (this *SynchronousStation) deliver(receiverId int, pack *Pack) {
 	s := this.manager.getStationById(receiverId).(*SynchronousStation)
-	s.communicationChannel <- packToSend
-	this.SentMsgCounter += len(this.currentData)
+	s.mutex.Lock()
+	if s.stopped {
+		s.UndeliveredMsgCounter += len(pack.Data)
+		this.manager.observers.messageDropped(this.manager.round, pack, receiverId)
+	} else {
+		s.nextInbox = append(s.nextInbox, pack)
+	}
+	s.mutex.Unlock()
+	this.SentMsgCounter += len(pack.Data)
+	atomic.AddInt64(&this.manager.roundSentMsgs, 1)
+	this.manager.observers.messageSent(this.manager.round, pack, receiverId)
 }
 
+// receiveMsgs - moves messages sent in previous round to message queue, called between barriers,
+// when no station sends messages
 func (this *SynchronousStation) receiveMsgs() {
-	for msg := range this.communicationChannel {
+	this.mutex.Lock()
+	inbox := this.nextInbox
+	this.nextInbox = make([]*Pack, 0, len(inbox))
+	this.mutex.Unlock()
+
+	sortBySender(inbox)
+	for _, msg := range inbox {
 		this.historicalDataForStats = append(this.historicalDataForStats, msg.Data)
 		this.msgQueue.Enqueue(msg)
 		this.ReceivedMsgCounter += len(msg.Data)
+		this.manager.observers.messageDelivered(this.manager.round, msg, this.id)
 	}
 }
 
 // Broadcast - function used for broadcasting information to neighbours
 func (this *SynchronousStation) Broadcast() {
+	pack := NewPack(this.currentData, this.RoundCounter, this.id)
 	this.graph.GraphStructure.Visit(this.id, func(w int, c int64) (skip bool) {
-		this.sendMsgToStation(w)
+		this.deliver(w, pack)
 		return
 	})
 }
 
 // SynchronizedBroadcast - thread-safe function used for broadcasting information to neighbours
 func (this *SynchronousStation) SynchronizedBroadcast() {
+	pack := NewPack(this.currentData, this.RoundCounter, this.id)
 	this.graph.GraphStructure.Visit(this.id, func(w int, c int64) (skip bool) {
-		s := this.manager.getStationById(w).(*SynchronousStation)
-		s.mutex.Lock()
-		this.sendMsgToStation(w)
-		s.mutex.Unlock()
+		this.deliver(w, pack)
 		return
 	})
 }
 
+// Send - thread-safe function used for sending given data to neighbour, returns false if link to receiver is broken,
+// data sent to station which already stopped is discarded and counted as undelivered by receiver
+func (this *SynchronousStation) Send(receiverId int, data []float64) bool {
+	if !this.graph.GraphStructure.Edge(this.id, receiverId) {
+		return false
+	}
+
+	this.deliver(receiverId, NewPack(data, this.RoundCounter, this.id))
+	return true
+}
+
 func (this *SynchronousStation) GetStation() Station {
 	return *this.Station
 }
@@ -0,0 +1,71 @@
+package simulation
+
+import (
+	"app/simulationGraph"
+	"testing"
+	"time"
+)
+
+// repeatedSendProtocol - sends the same value several times to every neighbour in each of given number of rounds
+type repeatedSendProtocol struct {
+	copies int
+	rounds int
+}
+
+func (repeatedSendProtocol) GetInitialData(station IStation) {
+	station.SetCurrentData([]float64{float64(station.GetId())})
+}
+
+func (p repeatedSendProtocol) OnInitialize(station IStation) {
+	p.OnDataPropagate(station)
+}
+
+func (repeatedSendProtocol) OnDataReceive(station IStation) {
+	mq := station.GetMsgQueue()
+	for mq.Len() > 0 {
+		mq.Dequeue()
+	}
+}
+
+func (p repeatedSendProtocol) OnDataPropagate(station IStation) {
+	for _, w := range station.GetNeighbours() {
+		for i := 0; i < p.copies; i++ {
+			station.Send(w, station.GetCurrentData())
+		}
+	}
+}
+
+func (p repeatedSendProtocol) StopCondition(station IStation) bool {
+	return station.GetRoundCounter() < p.rounds
+}
+
+func (repeatedSendProtocol) OnFinalize(station IStation) {}
+
+func (repeatedSendProtocol) CalculateStationExactResult(station IStation) float64 { return -1 }
+
+func (repeatedSendProtocol) CalculateGlobalExactResult(stations *[]IStation) float64 { return -1 }
+
+func TestSeveralMessagesToNeighbourInRound(t *testing.T) {
+	for _, engine := range []string{GoroutineEngine, SequentialEngine} {
+		g := simulationGraph.BuildGrid(4, 4, "", "")
+		manager := NewManager("", g)
+		if err := manager.SetEngine(engine, 1); err != nil {
+			t.Fatal(err)
+		}
+
+		p := repeatedSendProtocol{copies: 3, rounds: 5}
+		done := make(chan JsonStatsStructure)
+		go func() { done <- manager.RunSimulation(p) }()
+
+		select {
+		case result := <-done:
+			// 24 edges, both directions, round 0 and every round but the last one is received
+			expected := 2 * 24 * p.copies * p.rounds
+			if result.AllReceivedMsgs != expected {
+				t.Errorf("%s engine: %d messages received, %d expected", engine, result.AllReceivedMsgs, expected)
+			}
+		case <-time.After(30 * time.Second):
+			t.Fatalf("%s engine: simulation did not finish", engine)
+		}
+	}
+}
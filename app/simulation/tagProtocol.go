@@ -0,0 +1,141 @@
+package simulation
+
+import (
+	"fmt"
+	"math"
+)
+
+// TagProtocol - TAG-style aggregation: BFS spanning tree is built from root, partial aggregates
+// (sum, count, min, max) are convergecast up the tree and result is broadcast down the tree
+type TagProtocol struct {
+	aggregate string
+	minValue  float64
+	maxValue  float64
+	tree      treeAggregation
+}
+
+// tagState - state of station in TAG aggregation
+type tagState struct {
+	tree treeState
+}
+
+func init() {
+	RegisterProtocol(ProtocolInfo{
+		Name:        "tag",
+		Description: "aggregate observed values (sum|count|min|max|avg) over BFS spanning tree",
+		Parameters: []ProtocolParameter{
+			{Name: "aggregate", Type: StringParameter, Default: "avg", Description: "sum|count|min|max|avg"},
+			{Name: "root", Type: IntParameter, Default: "0", Description: "root of spanning tree"},
+			{Name: "min", Type: FloatParameter, Default: "0", Description: "minimal observed value (uniform distribution)"},
+			{Name: "max", Type: FloatParameter, Default: "100", Description: "maximal observed value"},
+		},
+		Factory: func(params ProtocolParams) (Protocol, error) {
+			p := &TagProtocol{
+				aggregate: params.String("aggregate"),
+				minValue:  params.Float("min"),
+				maxValue:  params.Float("max"),
+			}
+			if _, ok := tagAggregates[p.aggregate]; !ok {
+				return nil, fmt.Errorf("unknown aggregate %q (sum|count|min|max|avg)", p.aggregate)
+			}
+			if p.minValue > p.maxValue {
+				return nil, fmt.Errorf("min value %v is greater than max value %v", p.minValue, p.maxValue)
+			}
+
+			p.tree = treeAggregation{root: params.Int("root"), merge: mergeTagPartials, finish: p.finish}
+			return WithState[tagState](p), nil
+		},
+	})
+}
+
+// tagAggregates - evaluates aggregate from partial state record (sum, count, min, max)
+var tagAggregates = map[string]func(partial []float64) float64{
+	"sum":   func(partial []float64) float64 { return partial[0] },
+	"count": func(partial []float64) float64 { return partial[1] },
+	"min":   func(partial []float64) float64 { return partial[2] },
+	"max":   func(partial []float64) float64 { return partial[3] },
+	"avg":   func(partial []float64) float64 { return partial[0] / partial[1] },
+}
+
+func mergeTagPartials(a, b []float64) []float64 {
+	return []float64{a[0] + b[0], a[1] + b[1], math.Min(a[2], b[2]), math.Max(a[3], b[3])}
+}
+
+func (p *TagProtocol) finish(partial []float64) []float64 {
+	return []float64{tagAggregates[p.aggregate](partial)}
+}
+
+func (p *TagProtocol) GetInitialData(station IStation, state *tagState) {
+	value := p.minValue + station.GetRandom().Float64()*(p.maxValue-p.minValue)
+	station.ObserveValue([]float64{value})
+	station.SetCurrentData([]float64{value, 1, value, value})
+}
+
+func (p *TagProtocol) OnInitialize(station IStation, state *tagState) {
+	p.tree.initialize(station, &state.tree, station.GetCurrentData())
+}
+
+func (p *TagProtocol) OnDataReceive(station IStation, state *tagState) {
+	p.tree.receive(station, &state.tree)
+}
+
+func (p *TagProtocol) OnDataPropagate(station IStation, state *tagState) {
+	p.tree.propagate(station, &state.tree)
+}
+
+func (p *TagProtocol) StopCondition(station IStation, state *tagState) bool {
+	return station.GetRoundCounter() < treeAggregationRounds(station.GetGraph().GetDiameter())
+}
+
+func (p *TagProtocol) OnFinalize(station IStation, state *tagState) {
+	if result, ok := p.tree.result(&state.tree); ok {
+		station.SetResult(result[0])
+	}
+}
+
+func (p *TagProtocol) CalculateStationExactResult(station IStation, state *tagState) float64 {
+	return -1
+}
+
+func (p *TagProtocol) CalculateGlobalExactResult(stations *[]IStation) float64 {
+	var partial []float64
+	for _, station := range *stations {
+		value := station.GetObservedValues()[0][0]
+		local := []float64{value, 1, value, value}
+		if partial == nil {
+			partial = local
+		} else {
+			partial = mergeTagPartials(partial, local)
+		}
+	}
+
+	return tagAggregates[p.aggregate](partial)
+}
+
+func (p *TagProtocol) CalculateProtocolStats(stations *[]IStation) map[string]interface{} {
+	withoutResult := 0
+	treeDepth := 0
+	roundsToResult := 0
+	for _, station := range *stations {
+		state := &StateOf[tagState](station).tree
+		if !state.hasResult {
+			withoutResult++
+		} else if state.resultRound+1 > roundsToResult {
+			// round 0 is initialization, result received in i-th iteration belongs to round i+1
+			roundsToResult = state.resultRound + 1
+		}
+		if state.depth > treeDepth {
+			treeDepth = state.depth
+		}
+	}
+	if withoutResult > 0 {
+		roundsToResult = -1
+	}
+
+	return map[string]interface{}{
+		"aggregate":               p.aggregate,
+		"tree_depth":              treeDepth,
+		"rounds_to_result":        roundsToResult,
+		"stations_without_result": withoutResult,
+	}
+}
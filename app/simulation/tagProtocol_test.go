@@ -0,0 +1,40 @@
+package simulation
+
+import (
+	"app/simulationGraph"
+	"math"
+	"testing"
+)
+
+func TestTagAggregatesMatchExactResult(t *testing.T) {
+	tests := []struct {
+		name     string
+		g        *simulationGraph.GraphWrapper
+		diameter int
+		root     string
+		depth    int // eccentricity of root
+	}{
+		{"path", simulationGraph.BuildPath(6, "", "0"), 5, "2", 3},
+		{"grid", simulationGraph.BuildGrid(4, 4, "", "0"), 6, "0", 6},
+		{"tree", simulationGraph.BuildDAryTree(13, 3, "", "0"), 4, "0", 2},
+	}
+
+	for _, test := range tests {
+		for _, aggregate := range []string{"sum", "count", "min", "max", "avg"} {
+			result := runOnGraph(t, "tag", "aggregate="+aggregate+",root="+test.root, test.g, test.diameter)
+			if aggregate == "count" && result.Result != float64(len(result.Stations)) {
+				t.Errorf("%s: exact count is %v", test.name, result.Result)
+			}
+			for _, station := range result.Stations {
+				// partial sums are added in tree order, so sum and avg may differ in rounding
+				if math.Abs(station.Result-result.Result) > 1e-9*math.Abs(result.Result) {
+					t.Errorf("%s, %s: station %d computed %v, exact result is %v",
+						test.name, aggregate, station.GetId(), station.Result, result.Result)
+				}
+			}
+			if depth := result.ProtocolStats["tree_depth"]; depth != test.depth {
+				t.Errorf("%s, %s: tree depth %v, expected %d", test.name, aggregate, depth, test.depth)
+			}
+		}
+	}
+}
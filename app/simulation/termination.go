@@ -0,0 +1,144 @@
+package simulation
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	// TerminationByProtocol - every station stops when protocol's StopCondition returns false
+	TerminationByProtocol = "protocol"
+	// TerminationByQuiescence - simulation ends after round in which no message was sent
+	TerminationByQuiescence = "quiescence"
+	// TerminationByStableState - simulation ends when no station changed its state for k rounds
+	TerminationByStableState = "stable"
+)
+
+// Termination - global termination mode of simulation, in engine level modes (quiescence, stable)
+// protocol's StopCondition is ignored and all stations stop in the same round
+type Termination struct {
+	Mode string
+	// StableRounds - number of rounds without state change ending simulation (stable mode)
+	StableRounds int
+	// MaxRounds - limit of rounds in engine level modes, protocols which send messages in every round
+	// never become quiescent or stable
+	MaxRounds int
+}
+
+// ParseTermination - parses termination mode ("protocol", "quiescence" or "stable,$k"),
+// engine level modes require positive limit of rounds
+func ParseTermination(mode string, maxRounds int) (Termination, error) {
+	parts := strings.Split(mode, ",")
+	if parts[0] == "" || parts[0] == TerminationByProtocol {
+		return Termination{Mode: TerminationByProtocol}, nil
+	}
+	if maxRounds <= 0 {
+		return Termination{}, fmt.Errorf("%s termination requires positive max rounds, got %d", parts[0], maxRounds)
+	}
+
+	switch parts[0] {
+	case TerminationByQuiescence:
+		if len(parts) != 1 {
+			return Termination{}, fmt.Errorf("quiescence termination takes no arguments")
+		}
+		return Termination{Mode: TerminationByQuiescence, MaxRounds: maxRounds}, nil
+	case TerminationByStableState:
+		if len(parts) != 2 {
+			return Termination{}, fmt.Errorf("stable termination requires number of rounds (stable,$k)")
+		}
+		k, err := strconv.Atoi(parts[1])
+		if err != nil || k <= 0 {
+			return Termination{}, fmt.Errorf("invalid number of stable rounds %q", parts[1])
+		}
+		return Termination{Mode: TerminationByStableState, StableRounds: k, MaxRounds: maxRounds}, nil
+	}
+
+	return Termination{}, fmt.Errorf("unknown termination mode %q (protocol|quiescence|stable,$k)", parts[0])
+}
+
+// isEngineLevel - returns true if simulation end is decided by engine instead of protocol
+func (t Termination) isEngineLevel() bool {
+	return t.Mode == TerminationByQuiescence || t.Mode == TerminationByStableState
+}
+
+// stateFingerprint - returns hash of station's current data and user defined variables,
+// pointers, slices and maps are followed, so in-place modifications are detected
+func stateFingerprint(station *Station) uint64 {
+	h := fnv.New64a()
+	visited := map[uintptr]struct{}{}
+	hashValue(h, reflect.ValueOf(station.currentData), visited)
+	hashValue(h, reflect.ValueOf(station.userDefinedVariables), visited)
+	if station.state != nil {
+		hashValue(h, reflect.ValueOf(station.state), visited)
+	}
+	return h.Sum64()
+}
+
+func hashValue(h io.Writer, v reflect.Value, visited map[uintptr]struct{}) {
+	write := func(x uint64) {
+		var b [8]byte
+		for i := range b {
+			b[i] = byte(x >> (8 * i))
+		}
+		h.Write(b[:])
+	}
+
+	switch v.Kind() {
+	case reflect.Invalid:
+		write(0)
+	case reflect.Bool:
+		if v.Bool() {
+			write(1)
+		} else {
+			write(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		write(uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		write(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		write(math.Float64bits(v.Float()))
+	case reflect.String:
+		h.Write([]byte(v.String()))
+	case reflect.Ptr:
+		if v.IsNil() {
+			write(0)
+			return
+		}
+		// pointers on current path are skipped, so cyclic structures terminate
+		if _, ok := visited[v.Pointer()]; ok {
+			return
+		}
+		visited[v.Pointer()] = struct{}{}
+		hashValue(h, v.Elem(), visited)
+		delete(visited, v.Pointer())
+	case reflect.Interface:
+		hashValue(h, v.Elem(), visited)
+	case reflect.Slice, reflect.Array:
+		write(uint64(v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			hashValue(h, v.Index(i), visited)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			hashValue(h, v.Field(i), visited)
+		}
+	case reflect.Map:
+		// map iteration order is random, entries are combined in order independent way
+		sum := uint64(0)
+		iter := v.MapRange()
+		for iter.Next() {
+			entry := fnv.New64a()
+			hashValue(entry, iter.Key(), visited)
+			hashValue(entry, iter.Value(), visited)
+			sum += entry.Sum64()
+		}
+		write(uint64(v.Len()))
+		write(sum)
+	}
+}
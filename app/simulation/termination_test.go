@@ -0,0 +1,113 @@
+package simulation
+
+import (
+	"app/simulationGraph"
+	"testing"
+)
+
+func TestParseTermination(t *testing.T) {
+	tests := []struct {
+		mode      string
+		maxRounds int
+		expected  Termination
+		valid     bool
+	}{
+		{"", 0, Termination{Mode: TerminationByProtocol}, true},
+		{"protocol", 0, Termination{Mode: TerminationByProtocol}, true},
+		{"quiescence", 50, Termination{Mode: TerminationByQuiescence, MaxRounds: 50}, true},
+		{"stable,3", 50, Termination{Mode: TerminationByStableState, StableRounds: 3, MaxRounds: 50}, true},
+		{"quiescence", 0, Termination{}, false},
+		{"stable,3", -1, Termination{}, false},
+		{"quiescence,2", 50, Termination{}, false},
+		{"stable", 50, Termination{}, false},
+		{"stable,0", 50, Termination{}, false},
+		{"silence", 50, Termination{}, false},
+	}
+
+	for _, test := range tests {
+		termination, err := ParseTermination(test.mode, test.maxRounds)
+		if (err == nil) != test.valid || termination != test.expected {
+			t.Errorf("ParseTermination(%q, %d) = %+v, %v", test.mode, test.maxRounds, termination, err)
+		}
+	}
+}
+
+func TestEngineLevelTermination(t *testing.T) {
+	// floodMax on path of 6 stations: the last station learns about leader in round rounds_to_agreement,
+	// optimized variant sends messages only in rounds in which leader changed, basic variant in every round
+	tests := []struct {
+		params      string
+		termination string
+		// extraRounds - rounds of simulation after agreement
+		extraRounds int
+	}{
+		{"variant=optimized", "quiescence", 1},
+		{"variant=basic", "stable,1", 1},
+		{"variant=basic", "stable,3", 3},
+		{"variant=optimized", "stable,2", 2},
+	}
+
+	for _, engine := range []string{SequentialEngine, GoroutineEngine} {
+		for _, test := range tests {
+			conf := graphRunConfig("floodMax", test.params, simulationGraph.BuildPath(6, "", "0"), 5)
+			conf.Engine = engine
+			conf.Termination = test.termination
+			conf.MaxRounds = 100
+			result := runConfig(t, conf)
+
+			if wrong := result.ProtocolStats["stations_with_wrong_leader"]; wrong != 0 {
+				t.Fatalf("%s, %s: %v stations with wrong leader", engine, test.termination, wrong)
+			}
+			agreement := result.ProtocolStats["rounds_to_agreement"].(int)
+			if result.NofRounds != agreement+test.extraRounds {
+				t.Errorf("%s, %s %s: expected %d rounds after agreement in round %d, got %d rounds",
+					engine, test.params, test.termination, test.extraRounds, agreement, result.NofRounds)
+			}
+			for _, station := range result.Stations {
+				if station.RoundCounter != result.NofRounds {
+					t.Errorf("%s, %s: station %d stopped after %d of %d rounds",
+						engine, test.termination, station.GetId(), station.RoundCounter, result.NofRounds)
+				}
+			}
+		}
+	}
+}
+
+func TestMaxRoundsEndsRunWithoutQuiescence(t *testing.T) {
+	// basic floodMax sends messages in every round, so only limit of rounds ends the run
+	conf := graphRunConfig("floodMax", "variant=basic", simulationGraph.BuildPath(6, "", "0"), 5)
+	conf.Termination = TerminationByQuiescence
+	conf.MaxRounds = 12
+	if result := runConfig(t, conf); result.NofRounds != 12 {
+		t.Errorf("expected 12 rounds, got %d", result.NofRounds)
+	}
+}
+
+func TestHopDistanceTermination(t *testing.T) {
+	// on path of 6 stations distances reach the farthest station after eccentricity of root rounds
+	// and acknowledgements return to root after the same number of rounds
+	tests := []struct {
+		root         string
+		distances    []float64
+		eccentricity int
+	}{
+		{"0", []float64{0, 1, 2, 3, 4, 5}, 5},
+		{"3", []float64{3, 2, 1, 0, 1, 2}, 3},
+	}
+
+	for _, test := range tests {
+		result := runOnGraph(t, "hopDistance", "root="+test.root, simulationGraph.BuildPath(6, "", "0"), 5)
+		for i, station := range result.Stations {
+			if station.Result != test.distances[i] {
+				t.Errorf("root %s: station %d computed distance %v, expected %v", test.root, i, station.Result, test.distances[i])
+			}
+		}
+		if result.Result != float64(test.eccentricity) {
+			t.Errorf("root %s: expected eccentricity %d, got %v", test.root, test.eccentricity, result.Result)
+		}
+		// messages received in i-th iteration belong to round i+1
+		if round := result.ProtocolStats["detection_round"]; round != 2*test.eccentricity+1 {
+			t.Errorf("root %s: expected detection in round %d, got %v", test.root, 2*test.eccentricity+1, round)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package simulation
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+)
+
+const (
+	traceHeaderEvent   = "header"
+	traceRoundEndEvent = "round_end"
+	traceSendEvent     = "send"
+	traceDeliverEvent  = "deliver"
+	traceDropEvent     = "drop"
+	traceStateEvent    = "state"
+	traceTopologyEvent = "topology"
+)
+
+// traceEvent - single record of trace file (JSON lines)
+type traceEvent struct {
+	Event    string       `json:"event"`
+	Round    int          `json:"round"`
+	Sender   *int         `json:"sender,omitempty"`
+	Receiver *int         `json:"receiver,omitempty"`
+	Station  *int         `json:"station,omitempty"`
+	Hash     uint64       `json:"hash,omitempty"`
+	Payload  []float64    `json:"payload,omitempty"`
+	Changes  []EdgeChange `json:"changes,omitempty"`
+	Header   *RunConfig   `json:"header,omitempty"`
+}
+
+// TraceRecorder - observer saving every send, delivery, drop, station state change and topology change
+// to JSON lines file, payloads are saved as hashes unless header requests them
+type TraceRecorder struct {
+	BaseObserver
+	file     *os.File
+	writer   *bufio.Writer
+	encoder  *json.Encoder
+	payloads bool
+	err      error
+}
+
+// NewTraceRecorder - creates trace file and writes header to it
+func NewTraceRecorder(path string, header RunConfig) (*TraceRecorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	writer := bufio.NewWriter(file)
+	recorder := &TraceRecorder{file: file, writer: writer, encoder: json.NewEncoder(writer), payloads: header.Payloads}
+	recorder.write(traceEvent{Event: traceHeaderEvent, Header: &header})
+	return recorder, recorder.err
+}
+
+func (t *TraceRecorder) write(event traceEvent) {
+	if t.err == nil {
+		t.err = t.encoder.Encode(event)
+	}
+}
+
+func (t *TraceRecorder) message(event string, round, senderId, receiverId int, data []float64) {
+	e := traceEvent{Event: event, Round: round, Sender: &senderId, Receiver: &receiverId, Hash: payloadHash(data)}
+	if t.payloads {
+		e.Payload = data
+	}
+	t.write(e)
+}
+
+func (t *TraceRecorder) OnMessageSent(round int, senderId int, receiverId int, data []float64) {
+	t.message(traceSendEvent, round, senderId, receiverId, data)
+}
+
+func (t *TraceRecorder) OnMessageDelivered(round int, senderId int, receiverId int, data []float64) {
+	t.message(traceDeliverEvent, round, senderId, receiverId, data)
+}
+
+func (t *TraceRecorder) OnMessageDropped(round int, senderId int, receiverId int, data []float64) {
+	t.message(traceDropEvent, round, senderId, receiverId, data)
+}
+
+func (t *TraceRecorder) OnStationStateChanged(round int, station IStation) {
+	id := station.GetId()
+	state := station.GetStation()
+	t.write(traceEvent{Event: traceStateEvent, Round: round, Station: &id, Hash: stateFingerprint(&state)})
+}
+
+func (t *TraceRecorder) OnTopologyChanged(round int, changes []EdgeChange) {
+	t.write(traceEvent{Event: traceTopologyEvent, Round: round, Changes: changes})
+}
+
+func (t *TraceRecorder) OnRoundEnd(round int) {
+	t.write(traceEvent{Event: traceRoundEndEvent, Round: round})
+}
+
+// Close - flushes and closes trace file, returns first error which occurred while writing
+func (t *TraceRecorder) Close() error {
+	if err := t.writer.Flush(); t.err == nil {
+		t.err = err
+	}
+	if err := t.file.Close(); t.err == nil {
+		t.err = err
+	}
+	if t.err != nil {
+		return fmt.Errorf("trace recording failed: %v", t.err)
+	}
+
+	return nil
+}
+
+// payloadHash - returns FNV-1a hash of message data
+func payloadHash(data []float64) uint64 {
+	h := fnv.New64a()
+	var b [8]byte
+	for _, v := range data {
+		bits := math.Float64bits(v)
+		for i := range b {
+			b[i] = byte(bits >> (8 * i))
+		}
+		h.Write(b[:])
+	}
+
+	return h.Sum64()
+}
@@ -0,0 +1,128 @@
+package simulation
+
+const (
+	treeJoinMsg = iota
+	treeAggregateMsg
+	treeResultMsg
+)
+
+type treePhase int
+
+const (
+	treeIdle             treePhase = iota // station has not joined the tree yet
+	treeJoining                           // station chose parent and announces itself in next propagation
+	treeAnnounced                         // station announced itself, neighbours answer in the round after next one
+	treeAwaitingChildren                  // station receives announcements of its children
+	treeCollecting                        // station waits for partial aggregates of its children
+	treeReporting                         // station sends its partial aggregate to parent in next propagation
+	treeWaiting                           // station waits for result from parent
+	treeForwarding                        // station sends result to its children in next propagation
+	treeDone
+)
+
+// treeAggregation - helper used by tree based protocols: BFS spanning tree construction from root,
+// convergecast of partial aggregates to the root and broadcast of the result down the tree
+type treeAggregation struct {
+	root int
+	// merge - combines two partial aggregates
+	merge func(a, b []float64) []float64
+	// finish - computes result from global aggregate (called at root)
+	finish func(aggregate []float64) []float64
+}
+
+// treeState - state of a station in tree aggregation (part of state of protocol using the helper)
+type treeState struct {
+	phase       treePhase
+	parent      int // -1 for root
+	depth       int
+	children    []int
+	nofReceived int // number of partial aggregates received from children
+	partial     []float64
+	result      []float64
+	resultRound int
+	hasResult   bool
+}
+
+// treeAggregationRounds - number of rounds sufficient to build tree, convergecast and broadcast result
+func treeAggregationRounds(diameter int) int {
+	return 3*diameter + 2
+}
+
+// initialize - sets up station state with local aggregate, root starts building the tree
+func (t treeAggregation) initialize(station IStation, state *treeState, local []float64) {
+	*state = treeState{phase: treeIdle, parent: -1, children: make([]int, 0), partial: local}
+
+	if station.GetId() == t.root {
+		state.phase = treeJoining
+		t.propagate(station, state)
+	}
+}
+
+// receive - processes tree messages from station's message queue
+func (t treeAggregation) receive(station IStation, state *treeState) {
+	mq := station.GetMsgQueue()
+	for mq.Len() > 0 {
+		msg := mq.Dequeue()
+		switch int(msg.Data[0]) {
+		case treeJoinMsg:
+			senderParent, senderDepth := int(msg.Data[1]), int(msg.Data[2])
+			if senderParent == station.GetId() {
+				state.children = append(state.children, msg.SenderId)
+			} else if state.phase == treeIdle || (state.phase == treeJoining && msg.SenderId < state.parent) {
+				// join the tree, parent with the lowest id wins
+				state.phase = treeJoining
+				state.parent = msg.SenderId
+				state.depth = senderDepth + 1
+			}
+		case treeAggregateMsg:
+			state.partial = t.merge(state.partial, msg.Data[1:])
+			state.nofReceived++
+		case treeResultMsg:
+			state.result = msg.Data[1:]
+			state.resultRound = station.GetRoundCounter()
+			state.hasResult = true
+			state.phase = treeForwarding
+		}
+	}
+
+	switch state.phase {
+	case treeAnnounced:
+		state.phase = treeAwaitingChildren
+	case treeAwaitingChildren:
+		state.phase = treeCollecting
+	}
+	if state.phase == treeCollecting && state.nofReceived == len(state.children) {
+		if station.GetId() == t.root {
+			state.result = t.finish(state.partial)
+			state.resultRound = station.GetRoundCounter()
+			state.hasResult = true
+			state.phase = treeForwarding
+		} else {
+			state.phase = treeReporting
+		}
+	}
+}
+
+// propagate - sends messages required by current phase
+func (t treeAggregation) propagate(station IStation, state *treeState) {
+	switch state.phase {
+	case treeJoining:
+		for _, w := range station.GetNeighbours() {
+			station.Send(w, []float64{treeJoinMsg, float64(state.parent), float64(state.depth)})
+		}
+		state.phase = treeAnnounced
+	case treeReporting:
+		station.Send(state.parent, append([]float64{treeAggregateMsg}, state.partial...))
+		state.phase = treeWaiting
+	case treeForwarding:
+		for _, w := range state.children {
+			station.Send(w, append([]float64{treeResultMsg}, state.result...))
+		}
+		state.phase = treeDone
+	}
+}
+
+// result - returns result received by station, false if result did not reach it
+func (t treeAggregation) result(state *treeState) ([]float64, bool) {
+	return state.result, state.hasResult
+}
@@ -0,0 +1,155 @@
+package simulation
+
+import "app/simulationGraph"
+
+// TriangleProtocol - triangle counting, every station sends its neighbour list to neighbours
+// and counts triangles as common neighbours with each of them; received lists are kept
+// in station's state until the end, so they are included in station's memory
+type TriangleProtocol struct {
+	// exactTriangles - number of triangles at each vertex of original topology
+	exactTriangles *exactValues
+}
+
+// triangleState - state of station counting triangles
+type triangleState struct {
+	// neighbourLists - neighbour lists received from neighbours (by sender)
+	neighbourLists map[int][]float64
+	clustering     float64
+}
+
+func init() {
+	RegisterProtocol(ProtocolInfo{
+		Name:        "triangles",
+		Description: "count triangles and local clustering coefficients by exchanging neighbour lists",
+		Factory: func(params ProtocolParams) (Protocol, error) {
+			return WithState[triangleState](TriangleProtocol{exactTriangles: newExactValues(countTriangles)}), nil
+		},
+	})
+}
+
+func (p TriangleProtocol) GetInitialData(station IStation, state *triangleState) {
+	neighbours := station.GetNeighbours()
+	data := make([]float64, 0, len(neighbours))
+	for _, w := range neighbours {
+		data = append(data, float64(w))
+	}
+
+	station.SetCurrentData(data)
+	state.neighbourLists = map[int][]float64{}
+}
+
+func (p TriangleProtocol) OnInitialize(station IStation, state *triangleState) {
+	station.Broadcast()
+}
+
+func (p TriangleProtocol) OnDataReceive(station IStation, state *triangleState) {
+	mq := station.GetMsgQueue()
+	for mq.Len() > 0 {
+		msg := mq.Dequeue()
+		state.neighbourLists[msg.SenderId] = msg.Data
+	}
+}
+
+func (p TriangleProtocol) OnDataPropagate(station IStation, state *triangleState) {}
+
+func (p TriangleProtocol) StopCondition(station IStation, state *triangleState) bool {
+	// neighbour lists are exchanged in a single round
+	return station.GetRoundCounter() < 1
+}
+
+func (p TriangleProtocol) OnFinalize(station IStation, state *triangleState) {
+	own := map[float64]struct{}{}
+	for _, w := range station.GetCurrentData() {
+		own[w] = struct{}{}
+	}
+
+	// every triangle at station is seen from both of its other vertices
+	commonNeighbours := 0
+	for w, list := range state.neighbourLists {
+		if _, ok := own[float64(w)]; !ok {
+			continue
+		}
+		for _, x := range list {
+			if _, ok := own[x]; ok {
+				commonNeighbours++
+			}
+		}
+	}
+
+	triangles := float64(commonNeighbours / 2)
+	station.SetResult(triangles)
+	state.clustering = clusteringCoefficient(triangles, len(own))
+}
+
+func clusteringCoefficient(triangles float64, degree int) float64 {
+	if degree < 2 {
+		return 0
+	}
+	return 2 * triangles / float64(degree*(degree-1))
+}
+
+func (p TriangleProtocol) CalculateStationExactResult(station IStation, state *triangleState) float64 {
+	return p.exactTriangles.of(station.GetGraph())[station.GetId()]
+}
+
+// CalculateGlobalExactResult - returns number of triangles in original topology
+func (p TriangleProtocol) CalculateGlobalExactResult(stations *[]IStation) float64 {
+	sum := 0.
+	for _, t := range p.exactTriangles.of((*stations)[0].GetGraph()) {
+		sum += t
+	}
+
+	return sum / 3
+}
+
+func (p TriangleProtocol) CalculateProtocolStats(stations *[]IStation) map[string]interface{} {
+	g := (*stations)[0].GetGraph()
+	exact := p.exactTriangles.of(g)
+	neighbours := g.GetOriginalNeighbours()
+
+	sum, clustering, exactClustering := 0., 0., 0.
+	wrongCount := 0
+	for _, station := range *stations {
+		id := station.GetId()
+		triangles := station.GetStation().Result
+		sum += triangles
+		clustering += StateOf[triangleState](station).clustering
+		exactClustering += clusteringCoefficient(exact[id], len(neighbours[id]))
+		if triangles != exact[id] {
+			wrongCount++
+		}
+	}
+	n := float64(len(*stations))
+
+	return map[string]interface{}{
+		"triangles":                     sum / 3,
+		"avg_clustering":                clustering / n,
+		"exact_avg_clustering":          exactClustering / n,
+		"stations_with_wrong_triangles": wrongCount,
+	}
+}
+
+// countTriangles - returns number of triangles at each vertex of original topology
+func countTriangles(g *simulationGraph.GraphWrapper) []float64 {
+	neighbours := g.GetOriginalNeighbours()
+	sets := make([]map[int]struct{}, len(neighbours))
+	for v, list := range neighbours {
+		sets[v] = map[int]struct{}{}
+		for _, w := range list {
+			sets[v][w] = struct{}{}
+		}
+	}
+
+	triangles := make([]float64, len(neighbours))
+	for v, list := range neighbours {
+		for i, w := range list {
+			for _, x := range list[i+1:] {
+				if _, ok := sets[w][x]; ok {
+					triangles[v]++
+				}
+			}
+		}
+	}
+
+	return triangles
+}
@@ -0,0 +1,49 @@
+package simulation
+
+import (
+	"app/simulationGraph"
+	"testing"
+)
+
+// bowtieGraph - triangles 0-1-2 and 2-3-4 sharing station 2, with pendant edge 4-5
+func bowtieGraph() *simulationGraph.GraphWrapper {
+	edge := func(v, w uint) simulationGraph.JsonEdge {
+		return simulationGraph.JsonEdge{Edge: []uint{v, w}, Reliability: 1}
+	}
+	return simulationGraph.BuildGraphFromConfig(simulationGraph.JsonGraphStructure{Graph: simulationGraph.JsonGraph{
+		NofVertices: 6,
+		Edges:       []simulationGraph.JsonEdge{edge(0, 1), edge(1, 2), edge(0, 2), edge(2, 3), edge(3, 4), edge(2, 4), edge(4, 5)},
+	}})
+}
+
+func TestTrianglesOnKnownGraphs(t *testing.T) {
+	tests := []struct {
+		name     string
+		g        *simulationGraph.GraphWrapper
+		diameter int
+		// triangles - expected number of triangles at each station
+		triangles []float64
+		total     float64
+	}{
+		{"clique", simulationGraph.BuildClique(5, "", "0"), 1, []float64{6, 6, 6, 6, 6}, 10},
+		{"grid", simulationGraph.BuildGrid(3, 3, "", "0"), 4, make([]float64, 9), 0},
+		{"bowtie", bowtieGraph(), 3, []float64{1, 1, 2, 1, 1, 0}, 2},
+	}
+
+	for _, test := range tests {
+		result := runOnGraph(t, "triangles", "", test.g, test.diameter)
+		for i, station := range result.Stations {
+			if station.Result != test.triangles[i] {
+				t.Errorf("%s: station %d counted %v triangles, expected %v", test.name, i, station.Result, test.triangles[i])
+			}
+		}
+
+		if result.Result != test.total {
+			t.Errorf("%s: exact number of triangles is %v, expected %v", test.name, result.Result, test.total)
+		}
+		stats := result.ProtocolStats
+		if stats["triangles"] != test.total || stats["stations_with_wrong_triangles"] != 0 {
+			t.Errorf("%s: counted %v triangles, %v stations wrong", test.name, stats["triangles"], stats["stations_with_wrong_triangles"])
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package simulation
+
+// maxReportedViolations - limit of violations listed in statistics
+const maxReportedViolations = 100
+
+// validityReport - result of checking validity of distributed algorithm output
+type validityReport struct {
+	violations    []map[string]interface{}
+	nofViolations int
+}
+
+func (r *validityReport) addViolation(kind string, stations ...int) {
+	r.nofViolations++
+	if len(r.violations) < maxReportedViolations {
+		r.violations = append(r.violations, map[string]interface{}{"type": kind, "stations": stations})
+	}
+}
+
+func (r *validityReport) valid() float64 {
+	if r.nofViolations == 0 {
+		return 1
+	}
+	return 0
+}
+
+// stats - verdict and violations in form used by ProtocolStatsProvider
+func (r *validityReport) stats() map[string]interface{} {
+	violations := r.violations
+	if violations == nil {
+		violations = make([]map[string]interface{}, 0)
+	}
+
+	return map[string]interface{}{
+		"valid":          r.nofViolations == 0,
+		"nof_violations": r.nofViolations,
+		"violations":     violations,
+	}
+}
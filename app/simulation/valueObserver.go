@@ -0,0 +1,68 @@
+package simulation
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// valueObserver - draws integer values observed by stations, shared by count distinct and set protocols
+// so that they can be compared on the same observations
+type valueObserver struct {
+	nofObservables int // number of values observed by each station
+	minValue       int
+	maxValue       int
+}
+
+// observationParameters - parameters of valueObserver
+func observationParameters() []ProtocolParameter {
+	return []ProtocolParameter{
+		{Name: "observations", Type: IntParameter, Default: "20", Description: "number of values observed by each station"},
+		{Name: "min", Type: IntParameter, Default: "1", Description: "minimal observed value"},
+		{Name: "max", Type: IntParameter, Default: "1000000", Description: "maximal observed value"},
+	}
+}
+
+func newValueObserver(params ProtocolParams) (valueObserver, error) {
+	nofObservables := params.Int("observations")
+	if nofObservables <= 0 {
+		return valueObserver{}, fmt.Errorf("number of observations should be positive, got %d", nofObservables)
+	}
+
+	minValue, maxValue := params.Int("min"), params.Int("max")
+	if minValue > maxValue {
+		return valueObserver{}, fmt.Errorf("min value %d is greater than max value %d", minValue, maxValue)
+	}
+
+	return valueObserver{nofObservables: nofObservables, minValue: minValue, maxValue: maxValue}, nil
+}
+
+// observeValues - draws observed values from [minValue, maxValue] and returns them as bytes
+func (o valueObserver) observeValues(station IStation) [][]byte {
+	observedValuesAsBytes := make([][]byte, 0)
+	for i := 0; i < o.nofObservables; i++ {
+		randomValue := o.minValue + station.GetRandom().Intn(o.maxValue-o.minValue+1)
+		station.ObserveValue([]float64{float64(randomValue)})
+		observedValuesAsBytes = append(observedValuesAsBytes, valueToBytes(float64(randomValue)))
+	}
+
+	return observedValuesAsBytes
+}
+
+// valueToBytes - converts integer value to bytes used for hashing
+func valueToBytes(value float64) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, uint64(value))
+	return b
+}
+
+// exactUnion - returns set of values observed by all stations
+func exactUnion(stations *[]IStation) map[float64]struct{} {
+	m := map[float64]struct{}{}
+	for _, station := range *stations {
+		for _, i := range station.GetObservedValues() {
+			m[i[0]] = struct{}{}
+		}
+	}
+
+	return m
+}
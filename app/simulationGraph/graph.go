@@ -7,6 +7,7 @@ import (
 	"github.com/yourbasic/graph/build"
 	"log"
 	"math"
+	"sort"
 	"strings"
 )
 
@@ -16,10 +17,20 @@ type GraphWrapper struct {
 	reliabilityMap map[int]map[int]float64
 	diameter       int
 	edges          map[int]map[int]nothing
+	transitions    map[int]map[int]LinkTransition
+	attributes     map[int]map[int]map[string]float64
 }
 
 type nothing struct{}
 
+// LinkTransition - transition probabilities of link modelled as two-state Markov chain (Gilbert-Elliott)
+type LinkTransition struct {
+	// GoodToBad - probability that working link fails in a round
+	GoodToBad float64
+	// BadToGood - probability that broken link recovers in a round
+	BadToGood float64
+}
+
 func NewGraphWrapper(graphStructure *graph.Mutable, reliability map[int]map[int]float64, edges map[int]map[int]nothing) *GraphWrapper {
 	return &GraphWrapper{GraphStructure: graphStructure, reliabilityMap: reliability, diameter: 0, edges: edges}
 }
@@ -31,13 +42,24 @@ func BuildGraphFromConfig(conf JsonGraphStructure) *GraphWrapper {
 
 	var relMap = initRelMap(int(graphStructure.NofVertices))
 
+	var transitions = map[int]map[int]LinkTransition{}
+	var attributes = map[int]map[int]map[string]float64{}
 	for _, e := range graphStructure.Edges {
-		g.AddBoth(int(e.Edge[0]), int(e.Edge[1]))
-		addReliability(relMap, int(e.Edge[0]), int(e.Edge[1]), e.Reliability)
+		v, w := int(e.Edge[0]), int(e.Edge[1])
+		g.AddBoth(v, w)
+		addReliability(relMap, v, w, e.Reliability)
+		if e.PGoodBad != nil || e.PBadGood != nil {
+			addTransition(transitions, v, w, e)
+		}
+		if len(e.Attributes) > 0 {
+			addAttributes(attributes, v, w, e.Attributes)
+		}
 	}
 
 	edges := makeEdgeSet(g)
 	resultGraph := NewGraphWrapper(g, relMap, edges)
+	resultGraph.transitions = transitions
+	resultGraph.attributes = attributes
 	resultGraph.diameter = int(calcDiameter(resultGraph))
 	return resultGraph
 }
@@ -57,6 +79,34 @@ func addReliability(relMap map[int]map[int]float64, firstVertex int, secondVerte
 	relMap[secondVertex][firstVertex] = rel
 }
 
+func addTransition(transitions map[int]map[int]LinkTransition, v int, w int, e JsonEdge) {
+	if e.PGoodBad == nil || e.PBadGood == nil {
+		log.Fatalf("Edge [%d, %d] should define both p_gb and p_bg.", v, w)
+	}
+	t := LinkTransition{GoodToBad: *e.PGoodBad, BadToGood: *e.PBadGood}
+	if t.GoodToBad < 0 || t.GoodToBad > 1 || t.BadToGood < 0 || t.BadToGood > 1 {
+		log.Fatalf("Transition probabilities of edge [%d, %d] should be in range [0,1].", v, w)
+	}
+
+	for _, u := range []int{v, w} {
+		if transitions[u] == nil {
+			transitions[u] = map[int]LinkTransition{}
+		}
+	}
+	transitions[v][w] = t
+	transitions[w][v] = t
+}
+
+func addAttributes(attributes map[int]map[int]map[string]float64, v int, w int, values map[string]float64) {
+	for _, u := range []int{v, w} {
+		if attributes[u] == nil {
+			attributes[u] = map[int]map[string]float64{}
+		}
+	}
+	attributes[v][w] = values
+	attributes[w][v] = values
+}
+
 func BuildPath(nofVertices int, reliabilityModel string, pExpr string) *GraphWrapper {
 	g := graph.New(nofVertices)
 
@@ -243,6 +293,10 @@ func BuildGraphFromType(args config.AppArgs, buildWithDiameter bool) *GraphWrapp
 	params := strings.Split(args.GraphType, ",")
 	graphName := params[0]
 	var g *GraphWrapper
+	if utils.IsTimeVarying(args.Probability) {
+		// expression is evaluated by reliability model in every round
+		args.Probability = "0"
+	}
 
 	switch strings.ToLower(graphName) {
 	case "clique":
@@ -360,6 +414,34 @@ func (g *GraphWrapper) GetEdges() map[int]map[int]nothing {
 	return g.edges
 }
 
+// GetOriginalNeighbours - returns neighbours of every vertex in original topology (without updates of reliability model)
+// in ascending order
+func (g *GraphWrapper) GetOriginalNeighbours() [][]int {
+	neighbours := make([][]int, g.GraphStructure.Order())
+	for v, e := range g.edges {
+		for w := range e {
+			neighbours[v] = append(neighbours[v], w)
+			neighbours[w] = append(neighbours[w], v)
+		}
+	}
+	for _, n := range neighbours {
+		sort.Ints(n)
+	}
+
+	return neighbours
+}
+
 func (g *GraphWrapper) GetRelMap() map[int]map[int]float64 {
 	return g.reliabilityMap
 }
+
+// GetLinkTransition - returns transition probabilities of link given in graph file, false if link does not define them
+func (g *GraphWrapper) GetLinkTransition(v, w int) (LinkTransition, bool) {
+	t, ok := g.transitions[v][w]
+	return t, ok
+}
+
+// GetEdgeAttributes - returns attributes of edge given in graph file (nil if edge has no attributes)
+func (g *GraphWrapper) GetEdgeAttributes(v, w int) map[string]float64 {
+	return g.attributes[v][w]
+}
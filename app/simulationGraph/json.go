@@ -13,6 +13,11 @@ type JsonGraph struct {
 type JsonEdge struct {
 	Edge        []uint  `json:"edge"`
 	Reliability float64 `json:"reliability"`
+	// PGoodBad, PBadGood - optional transition probabilities of link used by gilbert-elliott reliability model
+	PGoodBad *float64 `json:"p_gb,omitempty"`
+	PBadGood *float64 `json:"p_bg,omitempty"`
+	// Attributes - optional named values of edge available as variables in reliability expressions
+	Attributes map[string]float64 `json:"attributes,omitempty"`
 }
 
 func NewJsonGraphStructure(g *GraphWrapper) *JsonGraphStructure {
@@ -21,7 +26,12 @@ func NewJsonGraphStructure(g *GraphWrapper) *JsonGraphStructure {
 	jsonEdges := make([]JsonEdge, 0)
 	for v, e := range edges {
 		for w, _ := range e {
-			jsonEdges = append(jsonEdges, JsonEdge{Edge: []uint{uint(v), uint(w)}, Reliability: g.GetRelMap()[v][w]})
+			jsonEdge := JsonEdge{Edge: []uint{uint(v), uint(w)}, Reliability: g.GetRelMap()[v][w],
+				Attributes: g.GetEdgeAttributes(v, w)}
+			if t, ok := g.GetLinkTransition(v, w); ok {
+				jsonEdge.PGoodBad, jsonEdge.PBadGood = &t.GoodToBad, &t.BadToGood
+			}
+			jsonEdges = append(jsonEdges, jsonEdge)
 		}
 	}
 
@@ -7,16 +7,31 @@ import (
 	"app/simulation"
 	"app/simulationGraph"
 	"fmt"
-	"math/rand"
+	"log"
 	"strings"
 	"time"
 )
 
 func main() {
-	rand.Seed(time.Now().UnixNano())
-
 	args := config.InitializeAppArgs()
-	if args.Experiment == "" {
+	seed := args.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	if args.ListProtocols {
+		printProtocols()
+	} else if args.Replay != "" {
+		replay(args)
+	} else if args.Resume != "" {
+		resume(args)
+	} else if args.Experiment == "" {
+		protocol := createProtocol(args)
+		termination, err := simulation.ParseTermination(args.Termination, args.MaxRounds)
+		if err != nil {
+			log.Fatal(err)
+		}
+
 		fmt.Println("Building graph.")
 		var g *simulationGraph.GraphWrapper
 		if args.GraphFile != "" {
@@ -27,24 +42,155 @@ func main() {
 		}
 
 		fmt.Println("Graph built.")
+		if err := simulation.ValidateProtocol(protocol, g.GraphStructure.Order()); err != nil {
+			log.Fatal(err)
+		}
 
 		if args.GraphCopyFile != "" {
 			io.SaveGraph(args.GraphCopyFile, g)
 		}
 		fmt.Println("Simulation pending.")
 		manager := simulation.NewManager(args.ReliabilityModel, g)
-		result := manager.RunSimulation(args.ProtocolName)
-
-		if args.StatsFile != "" {
-			io.SaveStatistics(args.StatsFile, result)
+		manager.SetTermination(termination)
+		manager.SetSeed(seed)
+		var scenario *simulation.Scenario
+		if args.ScenarioFile != "" {
+			if scenario, err = simulation.ReadScenario(args.ScenarioFile); err != nil {
+				log.Fatal(err)
+			}
+			if err := manager.SetScenario(scenario); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if err := manager.SetReliabilityExpression(args.Probability, args.ReliabilityRoot); err != nil {
+			log.Fatal(err)
+		}
+		if err := manager.SetEngine(args.Engine, args.Workers); err != nil {
+			log.Fatal(err)
+		}
+		var recorder *simulation.TraceRecorder
+		if args.TraceFile != "" {
+			recorder = createTraceRecorder(args, runConfig(args, seed, g, scenario))
+			manager.AddObserver(recorder)
+		}
+		if args.CheckpointFile != "" {
+			if err := manager.SetCheckpointing(args.CheckpointFile, args.CheckpointEvery, runConfig(args, seed, g, scenario)); err != nil {
+				log.Fatal(err)
+			}
+		}
+		result := manager.RunSimulation(protocol)
+		if recorder != nil {
+			if err := recorder.Close(); err != nil {
+				log.Fatal(err)
+			}
 		}
-		fmt.Println("Simulation finished.")
+
+		saveResult(args, manager, result)
 	} else {
 		experiment := strings.Split(args.Experiment, ",")[0]
 		if experiment == "extremaPropagation" {
 			experiments.ExtremaPropagationExperiment(args.Experiment)
 		} else if experiment == "countDistinct" {
 			experiments.CountDistinctExperiment(args.Experiment)
+		} else if experiment == "engineBenchmark" {
+			experiments.EngineBenchmarkExperiment(args.Experiment)
+		}
+	}
+}
+
+func createProtocol(args config.AppArgs) simulation.Protocol {
+	params, err := simulation.ParseProtocolParams(args.ProtocolParams)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	protocol, err := simulation.NewProtocol(args.ProtocolName, params)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return protocol
+}
+
+// runConfig - returns configuration needed to recreate simulation (trace header, checkpoints)
+func runConfig(args config.AppArgs, seed int64, g *simulationGraph.GraphWrapper, scenario *simulation.Scenario) simulation.RunConfig {
+	return simulation.RunConfig{
+		Protocol:         args.ProtocolName,
+		ProtocolParams:   args.ProtocolParams,
+		Seed:             seed,
+		Engine:           args.Engine,
+		NofWorkers:       args.Workers,
+		ReliabilityModel: args.ReliabilityModel,
+		Probability:      args.Probability,
+		ReliabilityRoot:  args.ReliabilityRoot,
+		Scenario:         scenario,
+		Termination:      args.Termination,
+		MaxRounds:        args.MaxRounds,
+		Diameter:         g.GetDiameter(),
+		Payloads:         args.TracePayloads,
+		Graph:            simulationGraph.NewJsonGraphStructure(g),
+	}
+}
+
+func createTraceRecorder(args config.AppArgs, conf simulation.RunConfig) *simulation.TraceRecorder {
+	recorder, err := simulation.NewTraceRecorder(args.TraceFile, conf)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return recorder
+}
+
+func saveResult(args config.AppArgs, manager *simulation.Manager, result simulation.JsonStatsStructure) {
+	if args.StatsFile != "" {
+		io.SaveStatistics(args.StatsFile, result)
+	}
+	if err := manager.CheckpointError(); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Simulation finished.")
+}
+
+func resume(args config.AppArgs) {
+	protocol, manager, conf, err := simulation.ResumeFromCheckpoint(args.Resume)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	checkpointFile := args.CheckpointFile
+	if checkpointFile == "" {
+		checkpointFile = args.Resume
+	}
+	if err := manager.SetCheckpointing(checkpointFile, args.CheckpointEvery, conf); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Simulation resumed.")
+	result := manager.RunSimulation(protocol)
+	saveResult(args, manager, result)
+}
+
+func replay(args config.AppArgs) {
+	fmt.Println("Replaying simulation.")
+	result, report, err := simulation.ReplayTrace(args.Replay)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if report.Diverged {
+		fmt.Printf("Replay diverged in round %d: %s\n", report.DivergenceRound, report.Divergence)
+	} else {
+		fmt.Printf("Replay verified, %d of %d rounds match.\n", report.VerifiedRounds, report.NofRounds)
+	}
+	if args.StatsFile != "" {
+		io.SaveStatistics(args.StatsFile, result)
+	}
+}
+
+func printProtocols() {
+	for _, info := range simulation.GetRegisteredProtocols() {
+		fmt.Printf("%s - %s\n", info.Name, info.Description)
+		for _, p := range info.Parameters {
+			fmt.Printf("    %s (%s, default %q) - %s\n", p.Name, p.Type, p.Default, p.Description)
 		}
 	}
 }
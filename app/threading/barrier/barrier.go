@@ -11,6 +11,8 @@ type Barrier struct {
 	m                    sync.Mutex
 	firstBarrierChannel  chan int
 	secondBarrierChannel chan int
+	// action - called by the last worker arriving at first barrier, before workers are released
+	action func()
 }
 
 func New(nofWorkers int) *Barrier {
@@ -28,10 +30,7 @@ func (b *Barrier) WaitAtFirstBarrier() {
 	b.m.Lock()
 	b.workerCounter += 1
 	if b.workerCounter == b.nofWorkers {
-		// close 2nd barrier
-		<-b.secondBarrierChannel
-		// open 1st barrier
-		b.firstBarrierChannel <- 1
+		b.releaseFirstBarrier()
 	}
 	b.m.Unlock()
 	<-b.firstBarrierChannel
@@ -52,6 +51,32 @@ func (b *Barrier) WaitAtSecondBarrier() {
 	b.secondBarrierChannel <- 1
 }
 
+// Deregister - removes calling worker from barrier, must be called instead of WaitAtFirstBarrier,
+// workers already waiting at first barrier are released if the calling worker was the last one missing
+func (b *Barrier) Deregister() {
+	b.m.Lock()
+	b.nofWorkers -= 1
+	if b.nofWorkers > 0 && b.workerCounter == b.nofWorkers {
+		b.releaseFirstBarrier()
+	}
+	b.m.Unlock()
+}
+
+func (b *Barrier) releaseFirstBarrier() {
+	if b.action != nil {
+		b.action()
+	}
+	// close 2nd barrier
+	<-b.secondBarrierChannel
+	// open 1st barrier
+	b.firstBarrierChannel <- 1
+}
+
+// SetAction - sets function called once per round when all workers reached first barrier
+func (b *Barrier) SetAction(action func()) {
+	b.action = action
+}
+
 func (b *Barrier) SetNofWorkers(nofActiveWorkers int) {
 	b.nofWorkers = nofActiveWorkers
 }
@@ -17,20 +17,55 @@ func ParseStrToPositiveInt(str string) int {
 	return number
 }
 
+// expressionFunctions - functions available in probability expressions
+var expressionFunctions = map[string]govaluate.ExpressionFunction{
+	"log":   mathFunction(math.Log),
+	"exp":   mathFunction(math.Exp),
+	"sin":   mathFunction(math.Sin),
+	"cos":   mathFunction(math.Cos),
+	"floor": mathFunction(math.Floor),
+	"min": func(args ...interface{}) (interface{}, error) {
+		return math.Min(args[0].(float64), args[1].(float64)), nil
+	},
+	"max": func(args ...interface{}) (interface{}, error) {
+		return math.Max(args[0].(float64), args[1].(float64)), nil
+	},
+}
+
+func mathFunction(f func(float64) float64) govaluate.ExpressionFunction {
+	return func(args ...interface{}) (interface{}, error) {
+		return f(args[0].(float64)), nil
+	}
+}
+
+// ParseExpression - parses probability expression
+func ParseExpression(expr string) (*govaluate.EvaluableExpression, error) {
+	return govaluate.NewEvaluableExpressionWithFunctions(expr, expressionFunctions)
+}
+
+// IsTimeVarying - returns true if probability expression uses variables other than n,
+// such expression is evaluated by reliability model in every round instead of once when graph is built
+func IsTimeVarying(expr string) bool {
+	expression, err := ParseExpression(expr)
+	if expr == "" || err != nil {
+		return false
+	}
+	for _, v := range expression.Vars() {
+		if v != "n" {
+			return true
+		}
+	}
+
+	return false
+}
+
 // EvaluateExpression - evaluates value based on given expression
 func EvaluateExpression(expr string, parameters map[string]interface{}) float64 {
 	if expr == "" {
 		return 0.0
 	}
-	functions := map[string]govaluate.ExpressionFunction{
-		"log": func(args ...interface{}) (interface{}, error) {
-			parameter := args[0].(float64)
-			result := math.Log(parameter)
-			return result, nil
-		},
-	}
 
-	expression, _ := govaluate.NewEvaluableExpressionWithFunctions(expr, functions)
+	expression, _ := ParseExpression(expr)
 	result, _ := expression.Evaluate(parameters)
 	p := result.(float64)
 	if p < 0 || p > 1 {